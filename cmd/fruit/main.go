@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fruit"
+)
+
+var verboseFlag bool
+var benchFlag bool
+var matrixFlag bool
+var nodeOrderFlag bool
+var chainOrderFlag bool
+var nodeConcFlag bool
+var chainConcFlag bool
+var loadFlag bool
+var mmapFlag bool
+var savePath string
+var descID string
+var ancID string
+var closureFlag bool
+var topCount int
+var anyQuery string
+var allQuery string
+var queryPath string
+var serveAddr string
+var workerCount int
+var fbFlag bool
+
+func init() {
+	flag.BoolVar(&verboseFlag, "v", false,
+		"Enable verbose output.",
+	)
+	flag.BoolVar(&matrixFlag, "m", false,
+		"Create transitive closure matrix.",
+	)
+	flag.BoolVar(&benchFlag, "b", false,
+		"If set, returns informations from computation and time.",
+	)
+	flag.BoolVar(&nodeOrderFlag, "no", false,
+		"Sets the chain decomposition algorithm to the node-order heuristic.",
+	)
+	flag.BoolVar(&chainOrderFlag, "co", false,
+		"Sets the chain decomposition algorithm to the chain-order heuristic.",
+	)
+	flag.BoolVar(&nodeConcFlag, "noc", false,
+		"Sets the chain decomposition algorithm to the node-order heuristic with path concatenation.",
+	)
+	flag.BoolVar(&chainConcFlag, "coc", false,
+		"Sets the chain decomposition algorithm to the chain-order heuristic with path concatenation.",
+	)
+	flag.BoolVar(&loadFlag, "load", false,
+		"Treat the input file as an index written with -save instead of a graph.",
+	)
+	flag.BoolVar(&mmapFlag, "mmap", false,
+		"Map the index given with -load into memory instead of reading it.",
+	)
+	flag.StringVar(&savePath, "save", "",
+		"Save the built index to the given file.",
+	)
+	flag.StringVar(&descID, "desc", "",
+		"Print the IDs of all vertices reachable from the vertex with the given ID.",
+	)
+	flag.StringVar(&ancID, "anc", "",
+		"Print the IDs of all vertices that can reach the vertex with the given ID.",
+	)
+	flag.BoolVar(&closureFlag, "closure", false,
+		"Print the number of reachable pairs of distinct vertices.",
+	)
+	flag.IntVar(&topCount, "top", 0,
+		"Print the IDs of the given number of vertices that reach the most vertices with their counts.",
+	)
+	flag.StringVar(&anyQuery, "any", "",
+		"Print whether any source reaches any target, given as comma-separated IDs in the form sources:targets.",
+	)
+	flag.StringVar(&allQuery, "all", "",
+		"Print whether every source reaches every target, given as comma-separated IDs in the form sources:targets.",
+	)
+	flag.StringVar(&queryPath, "q", "",
+		"Answer the queries \"source target\" read line by line from the given file or stdin if it is -.",
+	)
+	flag.StringVar(&serveAddr, "addr", "localhost:8080",
+		"Address the serve command listens on.",
+	)
+	flag.IntVar(&workerCount, "workers", 1,
+		"Number of goroutines condensing the graph and filling the indexing scheme.",
+	)
+	flag.BoolVar(&fbFlag, "fb", false,
+		"Find strongly connected components with the parallel forward-backward algorithm instead of Tarjan's.",
+	)
+}
+
+func buildOptionsFromFlags() fruit.BuildOptions {
+	options := fruit.BuildOptions{Workers: workerCount}
+	if fbFlag {
+		options.SCC = fruit.ForwardBackward
+	}
+	return options
+}
+
+func decompMethodFromFlags() fruit.DecompMethod {
+	if nodeOrderFlag {
+		return fruit.NodeOrder
+	} else if chainOrderFlag {
+		return fruit.ChainOrder
+	} else if nodeConcFlag {
+		return fruit.NodeOrderConcat
+	} else if chainConcFlag {
+		return fruit.ChainOrderConcat
+	}
+	return fruit.H3Concat
+}
+
+func printMatrix(matrix [][]bool) {
+	if !verboseFlag {
+		return
+	}
+	for i := 0; i < len(matrix); i++ {
+		fmt.Print("[ ")
+		for j := 0; j < len(matrix[i]); j++ {
+			if matrix[i][j] {
+				fmt.Print("T")
+			} else {
+				fmt.Print("F")
+			}
+			if j < len(matrix[i])-1 {
+				fmt.Print(", ")
+			}
+		}
+		fmt.Println(" ]")
+	}
+}
+
+// Returns the vertex ID given as s.
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid vertex ID %q", s)
+	}
+	return id, nil
+}
+
+// Returns the IDs of a comma-separated list.
+func parseIDs(s string) ([]int, error) {
+	var ids []int
+	for _, field := range strings.Split(s, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		id, err := parseID(field)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Prints the answer of a set query of the form sources:targets.
+func printSetQuery(query string, reachable func(sources, targets []int) (bool, error)) error {
+	sourceIDs, targetIDs, ok := strings.Cut(query, ":")
+	if !ok {
+		return fmt.Errorf("invalid set query %q, want sources:targets", query)
+	}
+	sources, err := parseIDs(sourceIDs)
+	if err != nil {
+		return err
+	}
+	targets, err := parseIDs(targetIDs)
+	if err != nil {
+		return err
+	}
+	answer, err := reachable(sources, targets)
+	if err != nil {
+		return err
+	}
+	fmt.Println(answer)
+	return nil
+}
+
+// Prints the IDs each passes for the vertex with the given ID, one per line.
+func printVertices(s string, each func(id int, f func(id int) bool) error) error {
+	id, err := parseID(s)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(os.Stdout)
+	err = each(id, func(id int) bool {
+		fmt.Fprintln(out, id)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// Prints the IDs of the ancestors of the vertex with the given ID.
+func printAncestors(idx *fruit.Index, s string) error {
+	if err := idx.CreateReverse(); err != nil {
+		return err
+	}
+	return printVertices(s, idx.Ancestors)
+}
+
+// Answers the queries in the file at path or stdin if path is -.
+func answerQueries(idx *fruit.Index, path string) error {
+	if path == "-" {
+		return idx.AnswerQueries(os.Stdin, os.Stdout, "stdin")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return idx.AnswerQueries(file, os.Stdout, path)
+}
+
+// Prints the ID and reach count of the count vertices that reach the most vertices.
+func printTopReachers(idx *fruit.Index, count int) error {
+	out := bufio.NewWriter(os.Stdout)
+	for _, id := range idx.TopReachers(count) {
+		reach, err := idx.ReachCount(id)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, id, reach)
+	}
+	return out.Flush()
+}
+
+// Reads the graph file and builds its index or loads a saved index if -load is set.
+// Without needsGraph the graph is only read into its compact form and dropped after the build.
+// Prints the build statistics if -b is set.
+func openIndex(file string, needsGraph bool) (*fruit.Index, error) {
+	totalStart := time.Now()
+	if loadFlag && mmapFlag {
+		return fruit.OpenMapped(file)
+	}
+	if loadFlag {
+		return fruit.Load(file)
+	}
+	start := time.Now()
+	var n, m int
+	var build func() (*fruit.Index, error)
+	if needsGraph {
+		g, err := fruit.ReadGraph(file)
+		if err != nil {
+			return nil, err
+		}
+		n, m = g.N(), g.M()
+		g.SetBuildOptions(buildOptionsFromFlags())
+		build = func() (*fruit.Index, error) { return fruit.CreateIndex(g, decompMethodFromFlags()) }
+	} else {
+		g, err := fruit.ReadCSR(file)
+		if err != nil {
+			return nil, err
+		}
+		n, m = g.N(), g.M()
+		g.SetBuildOptions(buildOptionsFromFlags())
+		build = func() (*fruit.Index, error) { return fruit.CreateIndexFromCSR(g, decompMethodFromFlags()) }
+	}
+
+	readingTime := float64(time.Since(start).Nanoseconds()) / 1e6
+	if verboseFlag {
+		fmt.Print("Read Graph (|V|=", n, ", |E|=", m, ").\n\n")
+	}
+	idx, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	if benchFlag {
+		stats := idx.Stats()
+		stats.ReadingTime = readingTime
+		stats.TotalTime = float64(time.Since(totalStart).Nanoseconds()) / 1e6
+		stats.Print(os.Stdout)
+	}
+	return idx, nil
+}
+
+// Prints err and exits if err is not nil.
+func check(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	// Subcommands precede the flags
+	command := ""
+	if len(os.Args) > 1 && (os.Args[1] == "repl" || os.Args[1] == "serve" || os.Args[1] == "coproc") {
+		command = os.Args[1]
+		flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
+	args := flag.Args()
+
+	fruit.SetVerbose(verboseFlag)
+
+	if len(args) < 1 {
+		fmt.Println("Usage: go run fruit [-v or -m or -b] [-no or -noc or -co or -coc] [-workers <n> [-fb]] [-load [-mmap]] [-save <index_path>] [-desc <id>] [-anc <id>] [-closure] [-top <k>] [-any|-all <ids>:<ids>] [-q <query_path>|-] <file_path>")
+		fmt.Println("       go run fruit repl [flags] <file_path>     (no line editing or history, use rlwrap)")
+		fmt.Println("       go run fruit serve [-addr <host:port>] [flags] <file_path>")
+		fmt.Println("       go run fruit coproc [flags] <file_path>")
+		return
+	}
+	// The subcommands need the graph for paths, other queries only the index
+	idx, err := openIndex(args[0], command != "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	defer idx.Close()
+
+	if command == "repl" {
+		info, err := os.Stdin.Stat()
+		check(err)
+		check(runREPL(idx, os.Stdin, os.Stdout, info.Mode()&os.ModeCharDevice != 0))
+		return
+	}
+	if command == "serve" {
+		fmt.Fprintln(os.Stderr, "Serving on", serveAddr)
+		check(http.ListenAndServe(serveAddr, fruit.CreateHandler(idx)))
+		return
+	}
+	if command == "coproc" {
+		check(idx.RunCoprocess(os.Stdin, os.Stdout))
+		return
+	}
+
+	if savePath != "" {
+		if err := idx.Save(savePath); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving index:", err)
+			os.Exit(1)
+		}
+	}
+
+	if matrixFlag {
+		printMatrix(idx.Matrix())
+	}
+	if descID != "" {
+		check(printVertices(descID, idx.Descendants))
+	}
+	if ancID != "" {
+		check(printAncestors(idx, ancID))
+	}
+	if closureFlag {
+		fmt.Println(idx.ClosureSize())
+	}
+	if topCount > 0 {
+		check(printTopReachers(idx, topCount))
+	}
+	if anyQuery != "" {
+		check(printSetQuery(anyQuery, idx.ReachableAny))
+	}
+	if allQuery != "" {
+		check(printSetQuery(allQuery, idx.ReachableAll))
+	}
+	if queryPath != "" {
+		check(answerQueries(idx, queryPath))
+	}
+}
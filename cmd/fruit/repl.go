@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"fruit"
+)
+
+const replHelp = `Commands:
+  reach <a> <b>  whether a reaches b
+  path <a> <b>   a path from a to b
+  desc <a>       vertices reachable from a
+  anc <b>        vertices that reach b
+  chain <a>      chain of a, components in brackets
+  scc <a>        strongly connected component of a
+  stats          statistics of the index
+  help           this text
+  quit           leave
+
+Lines have no editing or history, run the REPL under rlwrap for them.`
+
+type repl struct {
+	idx     *fruit.Index
+	out     *bufio.Writer
+	reverse bool // whether the reverse scheme for anc was created
+}
+
+// Answers the commands read line by line from in until quit or the end of in.
+// Failing commands print an error and do not end the loop.
+// Shows a prompt before every command if prompt is set.
+func runREPL(idx *fruit.Index, in io.Reader, out io.Writer, prompt bool) error {
+	r := &repl{idx: idx, out: bufio.NewWriter(out)}
+	scanner := bufio.NewScanner(in)
+	for {
+		if prompt {
+			fmt.Fprint(r.out, "> ")
+		}
+		if err := r.out.Flush(); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			break
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return r.out.Flush()
+		}
+		if err := r.run(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(r.out, "Error:", err)
+		}
+	}
+	if prompt {
+		fmt.Fprintln(r.out)
+	}
+	if err := r.out.Flush(); err != nil {
+		return err
+	}
+	return scanner.Err()
+}
+
+// Returns the IDs given as args or an error if there are not count of them.
+func replArgs(args []string, count int, usage string) ([]int, error) {
+	if len(args) != count {
+		return nil, errors.New("usage: " + usage)
+	}
+	ids := make([]int, count)
+	for i, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// Prints the IDs separated by spaces or none if there are none.
+func (r *repl) printIDs(ids []int) {
+	if len(ids) == 0 {
+		fmt.Fprintln(r.out, "none")
+		return
+	}
+	for i, id := range ids {
+		if i > 0 {
+			r.out.WriteByte(' ')
+		}
+		fmt.Fprint(r.out, id)
+	}
+	fmt.Fprintln(r.out)
+}
+
+// Prints the IDs each passes for the vertex with the given ID.
+func (r *repl) printEach(id int, each func(id int, f func(id int) bool) error) error {
+	var ids []int
+	err := each(id, func(id int) bool {
+		ids = append(ids, id)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	r.printIDs(ids)
+	return nil
+}
+
+func (r *repl) run(command string, args []string) error {
+	switch command {
+	case "reach":
+		ids, err := replArgs(args, 2, "reach <a> <b>")
+		if err != nil {
+			return err
+		}
+		reachable, err := r.idx.Reachable(ids[0], ids[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, reachable)
+	case "path":
+		ids, err := replArgs(args, 2, "path <a> <b>")
+		if err != nil {
+			return err
+		}
+		path, err := r.idx.Path(ids[0], ids[1])
+		if err != nil {
+			return err
+		}
+		if path == nil {
+			fmt.Fprintln(r.out, "no path")
+			return nil
+		}
+		for i, id := range path {
+			if i > 0 {
+				fmt.Fprint(r.out, " -> ")
+			}
+			fmt.Fprint(r.out, id)
+		}
+		fmt.Fprintln(r.out)
+	case "desc":
+		ids, err := replArgs(args, 1, "desc <a>")
+		if err != nil {
+			return err
+		}
+		return r.printEach(ids[0], r.idx.Descendants)
+	case "anc":
+		ids, err := replArgs(args, 1, "anc <b>")
+		if err != nil {
+			return err
+		}
+		if !r.reverse {
+			if err := r.idx.CreateReverse(); err != nil {
+				return err
+			}
+			r.reverse = true
+		}
+		return r.printEach(ids[0], r.idx.Ancestors)
+	case "chain":
+		ids, err := replArgs(args, 1, "chain <a>")
+		if err != nil {
+			return err
+		}
+		chain, pos, err := r.idx.Chain(ids[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "position %d of %d:", pos, len(chain))
+		for _, comp := range chain {
+			fmt.Fprint(r.out, " ", comp)
+		}
+		fmt.Fprintln(r.out)
+	case "scc":
+		ids, err := replArgs(args, 1, "scc <a>")
+		if err != nil {
+			return err
+		}
+		scc, err := r.idx.SCC(ids[0])
+		if err != nil {
+			return err
+		}
+		r.printIDs(scc)
+	case "stats":
+		s := r.idx.Stats()
+		layout := "dense"
+		if s.SchemeSparse {
+			layout = "sparse"
+		}
+		fmt.Fprintln(r.out, "vertices:", s.Nodes)
+		fmt.Fprintln(r.out, "edges:", s.Edges)
+		fmt.Fprintln(r.out, "SCCs:", s.SCCs)
+		fmt.Fprintln(r.out, "chains:", s.Chains)
+		fmt.Fprintf(r.out, "scheme: %s, %d entries of %d bytes\n", layout, s.SchemeEntries, s.SchemeWidth)
+	case "help":
+		fmt.Fprintln(r.out, replHelp)
+	default:
+		return fmt.Errorf("unknown command %q, see help", command)
+	}
+	return nil
+}
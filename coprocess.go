@@ -0,0 +1,133 @@
+package fruit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Request of the coprocess protocol. ID is any JSON value and is echoed in the
+// response, so requests can be sent without waiting for earlier answers.
+type CoprocessRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Op     string          `json:"op"`
+	Source *int            `json:"source,omitempty"`
+	Target *int            `json:"target,omitempty"`
+	Vertex *int            `json:"vertex,omitempty"`
+	Pairs  []PairResult    `json:"pairs,omitempty"`
+}
+
+// Response of the coprocess protocol. Result is null if Error is set.
+type CoprocessResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result any             `json:"result"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Answers the newline-delimited JSON requests read from r with one JSON
+// response per line on w, in the order of the requests. Supported ops are
+//
+//	reach  source, target  bool
+//	batch  pairs           [{"source", "target", "reachable"}, ...]
+//	path   source, target  IDs of a path or null
+//	desc   vertex          IDs of the descendants
+//	anc    vertex          IDs of the ancestors
+//	count  vertex          number of descendants
+//	meta                   IndexMeta
+//
+// Malformed requests are answered with an error and do not end the loop.
+// Every response is flushed before the next request is read.
+func (idx *Index) RunCoprocess(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<28)
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	reverse := false
+	for scanner.Scan() {
+		var request CoprocessRequest
+		var response CoprocessResponse
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			response.Error = err.Error()
+		} else {
+			response.ID = request.ID
+			if request.Op == "anc" && !reverse {
+				reverse = idx.CreateReverse() == nil
+			}
+			result, err := idx.answer(&request)
+			if err != nil {
+				response.Error = err.Error()
+			} else {
+				response.Result = result
+			}
+		}
+		if err := encoder.Encode(response); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Returns the result of a coprocess request.
+func (idx *Index) answer(request *CoprocessRequest) (any, error) {
+	vertex := func() (int, error) {
+		if request.Vertex == nil {
+			return 0, fmt.Errorf("op %s needs vertex", request.Op)
+		}
+		return *request.Vertex, nil
+	}
+	pair := func() (int, int, error) {
+		if request.Source == nil || request.Target == nil {
+			return 0, 0, fmt.Errorf("op %s needs source and target", request.Op)
+		}
+		return *request.Source, *request.Target, nil
+	}
+	collect := func(each func(v int, f func(w int) bool) error) (any, error) {
+		v, err := vertex()
+		if err != nil {
+			return nil, err
+		}
+		ids := []int{}
+		err = each(v, func(w int) bool {
+			ids = append(ids, w)
+			return true
+		})
+		return ids, err
+	}
+
+	switch request.Op {
+	case "reach":
+		s, t, err := pair()
+		if err != nil {
+			return nil, err
+		}
+		return idx.Reachable(s, t)
+	case "batch":
+		return idx.reachablePairs(request.Pairs)
+	case "path":
+		s, t, err := pair()
+		if err != nil {
+			return nil, err
+		}
+		return idx.Path(s, t)
+	case "desc":
+		return collect(idx.Descendants)
+	case "anc":
+		return collect(idx.Ancestors)
+	case "count":
+		v, err := vertex()
+		if err != nil {
+			return nil, err
+		}
+		return idx.ReachCount(v)
+	case "meta":
+		return idx.Meta(), nil
+	}
+	return nil, fmt.Errorf("unknown op %q", request.Op)
+}
@@ -0,0 +1,45 @@
+package fruit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunCoprocess(t *testing.T) {
+	g := readTestGraph("./test_graphs/collapse.gr", t)
+	idx := createTestIndex(g, t)
+
+	requests := strings.Join([]string{
+		`{"id": 1, "op": "reach", "source": 2, "target": 14}`,
+		`{"id": "b", "op": "batch", "pairs": [{"source": 14, "target": 2}]}`,
+		``,
+		`{"id": 3, "op": "path", "source": 9, "target": 2}`,
+		`{"id": 4, "op": "desc", "vertex": 10}`,
+		`{"id": 5, "op": "anc", "vertex": 2}`,
+		`{"id": 6, "op": "count", "vertex": 9}`,
+		`{"id": 7, "op": "reach", "source": 2, "target": 99}`,
+		`{"id": 8, "op": "reach", "source": 2}`,
+		`{"id": 9, "op": "fly"}`,
+		`{"id": 10,`,
+	}, "\n")
+	want := strings.Join([]string{
+		`{"id":1,"result":true}`,
+		`{"id":"b","result":[{"source":14,"target":2,"reachable":false}]}`,
+		`{"id":3,"result":null}`,
+		`{"id":4,"result":[17,16,18,9]}`,
+		`{"id":5,"result":[]}`,
+		`{"id":6,"result":0}`,
+		`{"id":7,"result":null,"error":"unknown vertex ID 99"}`,
+		`{"id":8,"result":null,"error":"op reach needs source and target"}`,
+		`{"id":9,"result":null,"error":"unknown op \"fly\""}`,
+		`{"id":null,"result":null,"error":"unexpected end of JSON input"}`,
+	}, "\n") + "\n"
+
+	var out strings.Builder
+	if err := idx.RunCoprocess(strings.NewReader(requests), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want {
+		t.Errorf("RunCoprocess wrote\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,63 @@
+package fruit
+
+import (
+	"sort"
+)
+
+// Returns the number of vertices other than the vertex with ID v that it can
+// reach in O(k). Sums the vertices of the chain suffixes v reaches using the
+// SCC sizes.
+func (idx *Index) ReachCount(v int) (int, error) {
+	u, err := idx.vertex(v)
+	if err != nil {
+		return 0, err
+	}
+	idx.buildTables()
+	comp := idx.vToComp[u]
+	return len(idx.members[comp]) - 1 + idx.compReachCount(comp), nil
+}
+
+// Returns the number of vertices outside of component c that c can reach.
+func (idx *Index) compReachCount(c int) int {
+	count := 0
+	idx.scheme.eachEntry(c, func(chain, pos int) {
+		sizes := idx.chainSizes[chain]
+		count += sizes[len(sizes)-1] - sizes[min(pos, len(sizes)-1)]
+	})
+	return count
+}
+
+// Returns the number of pairs (s, t) with s != t where s can reach t
+// in O(number of components * k).
+func (idx *Index) ClosureSize() int {
+	idx.buildTables()
+	size := 0
+	for c, members := range idx.members {
+		size += len(members) * (len(members) - 1 + idx.compReachCount(c))
+	}
+	return size
+}
+
+// Returns the IDs of up to count vertices that reach the most vertices,
+// ordered by decreasing ReachCount.
+func (idx *Index) TopReachers(count int) []int {
+	idx.buildTables()
+	reach := make([]int, len(idx.members))
+	for c, members := range idx.members {
+		reach[c] = len(members) - 1 + idx.compReachCount(c)
+	}
+	vertices := make([]int, 0, idx.n)
+	for v := 0; v < idx.n; v++ {
+		if _, ok := idx.ID(v); ok {
+			vertices = append(vertices, v)
+		}
+	}
+	sort.SliceStable(vertices, func(i, j int) bool {
+		return reach[idx.vToComp[vertices[i]]] > reach[idx.vToComp[vertices[j]]]
+	})
+	top := vertices[:max(min(count, len(vertices)), 0)]
+	for i, v := range top {
+		top[i], _ = idx.ID(v)
+	}
+	return top
+}
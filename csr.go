@@ -0,0 +1,186 @@
+package fruit
+
+import "math"
+
+// CSRGraph is the compact, immutable form of a graph that the index is built
+// from. The targets of the edges leaving v are out[outStart[v]:outStart[v+1]]
+// (compressed sparse rows) and the sources of the edges entering v are
+// in[inStart[v]:inStart[v+1]] (compressed sparse columns). Vertices are
+// stored as int32, so an edge takes 16 bytes in both directions together.
+// Rows list the edges in the order the adjacency lists of a Graph with the
+// same edges would, so both forms result in the same index.
+type CSRGraph struct {
+	n         int
+	m         int
+	outStart  []int
+	out       []int32
+	inStart   []int
+	in        []int32
+	inEdge    []int // position in out of every incoming edge
+	vToComp   []int
+	idMapping IdMapping
+	options   BuildOptions
+	stats     *BuildStats
+}
+
+// Returns ErrGraphSize if a graph with n vertices cannot be stored in the
+// compact form, whose vertices are int32.
+func checkCSRSize(n int) error {
+	if n > math.MaxInt32 {
+		return ErrGraphSize
+	}
+	return nil
+}
+
+// Creates the graph with the given outgoing rows and computes its incoming
+// ones in O(|V| + |E|). Incoming rows list their sources in descending order.
+// Panics with ErrGraphSize if the graph is too large for the compact form.
+func createCSRGraph(n int, outStart []int, out []int32) *CSRGraph {
+	if err := checkCSRSize(n); err != nil {
+		panic(err)
+	}
+	g := &CSRGraph{
+		n:        n,
+		m:        len(out),
+		outStart: outStart,
+		out:      out,
+		inStart:  make([]int, n+1),
+		in:       make([]int32, len(out)),
+		inEdge:   make([]int, len(out)),
+		stats:    &BuildStats{},
+	}
+	for _, w := range out {
+		g.inStart[w+1]++
+	}
+	for v := 0; v < n; v++ {
+		g.inStart[v+1] += g.inStart[v]
+	}
+	// Fill every incoming row from its end
+	cursor := make([]int, n)
+	copy(cursor, g.inStart[1:])
+	for v := 0; v < n; v++ {
+		for p := outStart[v]; p < outStart[v+1]; p++ {
+			w := out[p]
+			cursor[w]--
+			g.in[cursor[w]] = int32(v)
+			g.inEdge[cursor[w]] = p
+		}
+	}
+	return g
+}
+
+// Creates the graph with the given edges in O(|V| + |E|).
+// Like AddEdge, every edge is put in front of the earlier ones of its source.
+func createCSRGraphFromEdges(n int, sources, targets []int32) *CSRGraph {
+	outStart := make([]int, n+1)
+	for _, v := range sources {
+		outStart[v+1]++
+	}
+	for v := 0; v < n; v++ {
+		outStart[v+1] += outStart[v]
+	}
+	out := make([]int32, len(targets))
+	cursor := make([]int, n)
+	copy(cursor, outStart[1:])
+	for i, v := range sources {
+		cursor[v]--
+		out[cursor[v]] = targets[i]
+	}
+	return createCSRGraph(n, outStart, out)
+}
+
+// Returns the compact form of g in O(|V| + |E|).
+// Panics with ErrGraphSize if g is too large for it.
+func (g *Graph) csr() *CSRGraph {
+	if err := checkCSRSize(g.n); err != nil {
+		panic(err)
+	}
+	outStart := make([]int, g.n+1)
+	out := make([]int32, 0, g.m)
+	for v := 0; v < g.n; v++ {
+		for e := g.nodes[v].out; e != nil; e = e.next {
+			out = append(out, int32(e.target))
+		}
+		outStart[v+1] = len(out)
+	}
+	c := createCSRGraph(g.n, outStart, out)
+	c.idMapping = g.idMapping
+	c.options = g.options
+	return c
+}
+
+// Sets the options of the indexes built from g.
+func (g *CSRGraph) SetBuildOptions(options BuildOptions) {
+	g.options = options
+}
+
+// Returns the number of vertices of g.
+func (g *CSRGraph) N() int {
+	return g.n
+}
+
+// Returns the number of edges of g.
+func (g *CSRGraph) M() int {
+	return g.m
+}
+
+// Returns the targets of the edges leaving v.
+func (g *CSRGraph) outs(v int) []int32 {
+	return g.out[g.outStart[v]:g.outStart[v+1]]
+}
+
+// Returns the sources of the edges entering v.
+func (g *CSRGraph) ins(v int) []int32 {
+	return g.in[g.inStart[v]:g.inStart[v+1]]
+}
+
+func (g *CSRGraph) outDeg(v int) int {
+	return g.outStart[v+1] - g.outStart[v]
+}
+
+func (g *CSRGraph) inDeg(v int) int {
+	return g.inStart[v+1] - g.inStart[v]
+}
+
+// Returns the graph with all edges of g reversed in O(|V| + |E|).
+func (g *CSRGraph) reverse() *CSRGraph {
+	r := createCSRGraph(g.n, g.inStart, g.in)
+	r.options = g.options
+	return r
+}
+
+// Drops the removed edges from all rows in O(|V| + |E|),
+// keeping the order of the remaining ones.
+func (g *CSRGraph) compact(removed []bool) {
+	newPos := make([]int, len(g.out))
+	p := 0
+	for v := 0; v < g.n; v++ {
+		start := g.outStart[v]
+		g.outStart[v] = p
+		for q := start; q < g.outStart[v+1]; q++ {
+			if !removed[q] {
+				newPos[q] = p
+				g.out[p] = g.out[q]
+				p++
+			}
+		}
+	}
+	g.outStart[g.n] = p
+	g.out = g.out[:p]
+
+	j := 0
+	for v := 0; v < g.n; v++ {
+		start := g.inStart[v]
+		g.inStart[v] = j
+		for q := start; q < g.inStart[v+1]; q++ {
+			if !removed[g.inEdge[q]] {
+				g.in[j] = g.in[q]
+				g.inEdge[j] = newPos[g.inEdge[q]]
+				j++
+			}
+		}
+	}
+	g.inStart[g.n] = j
+	g.in = g.in[:j]
+	g.inEdge = g.inEdge[:j]
+}
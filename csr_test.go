@@ -0,0 +1,96 @@
+package fruit
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// Checks that every incoming edge of g points to its outgoing edge.
+func checkCSRGraph(g *CSRGraph, t *testing.T) {
+	t.Helper()
+	if len(g.out) != g.m || len(g.in) != g.m || g.outStart[g.n] != g.m || g.inStart[g.n] != g.m {
+		t.Fatalf("Graph has %d edges but rows of %d and %d edges", g.m, len(g.out), len(g.in))
+	}
+	for v := 0; v < g.n; v++ {
+		for j := g.inStart[v]; j < g.inStart[v+1]; j++ {
+			p := g.inEdge[j]
+			if int(g.out[p]) != v || p < g.outStart[g.in[j]] || p >= g.outStart[g.in[j]+1] {
+				t.Fatalf("Incoming edge %d -> %d points to the wrong outgoing edge", g.in[j], v)
+			}
+		}
+	}
+}
+
+func TestReadCSR(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			c, err := ReadCSR(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkCSRGraph(c, t)
+			// Rows keep the order of the adjacency lists
+			want := g.csr()
+			if c.n != want.n || !slices.Equal(c.out, want.out) || !slices.Equal(c.in, want.in) || !slices.Equal(c.outStart, want.outStart) {
+				t.Fatal("Read rows differ from the rows of the read graph")
+			}
+
+			idx, err := CreateIndexFromCSR(c, H3Concat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !compQuadraticMatrices(idx.Matrix(), createTestIndex(g, t).Matrix()) {
+				t.Error("Index of the compact graph answers differently")
+			}
+			if _, err := idx.Path(g.idMapping.vToId[0], g.idMapping.vToId[1]); !errors.Is(err, ErrNoGraph) {
+				t.Errorf("Path on index of compact graph returned %v, want %v", err, ErrNoGraph)
+			}
+		})
+	}
+	if _, err := ReadCSR("./test_graphs/missing.gr"); err == nil {
+		t.Error("Reading a missing file succeeded")
+	}
+}
+
+func TestCSRPhases(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_1000_1000.gr",
+		"./data/gnm/gnm_1000_10000.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			dag := readTestGraph(file, t).CollapseToDAG()
+			checkCSRGraph(dag, t)
+			m := dag.m
+			topo := dag.TopoSort()
+			decomp := dag.HthreeConcat(topo)
+			dag.RemoveTransitiveEdges(decomp)
+			checkCSRGraph(dag, t)
+			if removed := m - dag.m; removed != int(dag.stats.RemovedEdges) {
+				t.Errorf("Compaction dropped %d edges, %d were removed", removed, dag.stats.RemovedEdges)
+			}
+
+			dag.TopoSortOutEdges(topo)
+			checkCSRGraph(dag, t)
+			topoPos := make([]int, dag.n)
+			for i, v := range topo {
+				topoPos[v] = i
+			}
+			for v := 0; v < dag.n; v++ {
+				if !slices.IsSortedFunc(dag.outs(v), func(a, b int32) int { return topoPos[a] - topoPos[b] }) {
+					t.Fatalf("Outgoing edges of %d are not sorted topologically", v)
+				}
+			}
+		})
+	}
+}
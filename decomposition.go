@@ -1,4 +1,4 @@
-package main
+package fruit
 
 import (
 	"fmt"
@@ -52,7 +52,7 @@ func addToNewChain(id, v int, decomp *Decomposition) {
 // Traverses G starting from t in reversed direction (by traversing the incoming edges).
 // Reuses the visited array to close in search area.
 // Multiple runs are in O(|E| + l * (k_p - k_c)).
-func reversedDFS(t int, g *Graph, decomp *Decomposition, visited []bool) int {
+func reversedDFS(t int, g *CSRGraph, decomp *Decomposition, visited []bool) int {
 	stack := CreateStack[int](g.n)
 	head := CreateStack[int](g.n)
 
@@ -61,42 +61,42 @@ func reversedDFS(t int, g *Graph, decomp *Decomposition, visited []bool) int {
 		tChainNr = decomp.vToChain[t].chain.val.id
 	}
 
-	decompNodesProcessed++
+	g.stats.DecompNodes++
 	stack.Push(t)
 	for !stack.IsEmpty() {
-		v := stack.Peek()
+		v, _ := stack.Peek()
 
 		if !visited[v] {
 			// New vertex discovered
 			head.Push(v)
 			visited[v] = true
 			// Consider all incoming edges
-			for e := g.nodes[v].in; e != nil; e = e.next {
-				decompEdgesProcessed++
-				sChain := decomp.vToChain[e.source].chain
+			for _, s := range g.ins(v) {
+				g.stats.DecompEdges++
+				sChain := decomp.vToChain[s].chain
 
-				if sChain != nil && sChain.val.id != tChainNr && getLast(sChain).val == e.source {
-					// s (e.source) is last in a chain which is different from t's chain.
+				if sChain != nil && sChain.val.id != tChainNr && getLast(sChain).val == int(s) {
+					// s is last in a chain which is different from t's chain.
 					// Found chain with last vertex having a path to t.
 					for !head.IsEmpty() {
-						decompNodesProcessed++
-						w := head.Pop()
+						g.stats.DecompNodes++
+						w, _ := head.Pop()
 						visited[w] = false
 					}
-					return e.source
+					return int(s)
 				}
-				if !visited[e.source] {
-					decompNodesProcessed++
-					stack.Push(e.source)
+				if !visited[s] {
+					g.stats.DecompNodes++
+					stack.Push(int(s))
 				}
 			}
-		} else if visited[v] && !head.IsEmpty() && head.Peek() == v {
+		} else if top, err := head.Peek(); visited[v] && err == nil && top == v {
 			// Backtracking
-			decompNodesProcessed++
+			g.stats.DecompNodes++
 			head.Pop()
 			stack.Pop()
 		} else {
-			decompNodesProcessed++
+			g.stats.DecompNodes++
 			stack.Pop()
 		}
 	}
@@ -106,23 +106,23 @@ func reversedDFS(t int, g *Graph, decomp *Decomposition, visited []bool) int {
 // Finds a predecessor vertex that is the last one of its chain and has minimum outdegree
 // of all predecessors that are last in their respective chain.
 // Runs in O(N^-(v)).
-func findLastOfChainMinOutdegPre(v int, g *Graph, visited []bool, decomp *Decomposition) int {
+func findLastOfChainMinOutdegPre(v int, g *CSRGraph, visited []bool, decomp *Decomposition) int {
 	w := -1
 	minDeg := math.MaxInt
 
-	for e := g.nodes[v].in; e != nil; e = e.next {
-		decompEdgesProcessed++
-		chain := decomp.vToChain[e.source].chain
-		deg := g.nodes[e.source].outDeg
+	for _, s := range g.ins(v) {
+		g.stats.DecompEdges++
+		chain := decomp.vToChain[s].chain
+		deg := g.outDeg(int(s))
 
 		if chain == nil || getLast(chain) == nil {
 			continue
 		}
 
-		isLast := getLast(chain).val == e.source
-		if !visited[e.source] && isLast && deg <= minDeg {
+		isLast := getLast(chain).val == int(s)
+		if !visited[s] && isLast && deg <= minDeg {
 			minDeg = deg
-			w = e.source
+			w = int(s)
 		}
 	}
 	return w
@@ -130,13 +130,13 @@ func findLastOfChainMinOutdegPre(v int, g *Graph, visited []bool, decomp *Decomp
 
 // Finds a sccessor vertex that has v as single source.
 // Runs in O(N^+(v)).
-func findSingleSourceSucc(v int, visited []bool, g *Graph) int {
+func findSingleSourceSucc(v int, visited []bool, g *CSRGraph) int {
 	w := -1
 
-	for e := g.nodes[v].out; e != nil; e = e.next {
-		decompEdgesProcessed++
-		if !visited[e.target] && g.nodes[e.target].inDeg == 1 {
-			w = e.target
+	for _, t := range g.outs(v) {
+		g.stats.DecompEdges++
+		if !visited[t] && g.inDeg(int(t)) == 1 {
+			w = int(t)
 			break
 		}
 	}
@@ -175,7 +175,7 @@ func combineTwoChains(sLinkNode, tLinkNode *ListNode[Chain], decomp *Decompositi
 
 // Creates a path decomposition using the Chain-Order heuristic.
 // Runs in O(|V|+|E|).
-func (g *Graph) ChainOrderPathDecomp(topo []int) *Decomposition {
+func (g *CSRGraph) ChainOrderPathDecomp(topo []int) *Decomposition {
 	chains := createLinkedList[Chain]()
 	vToChain := make([]ChainMapping, g.n)
 	decomp := &Decomposition{vToChain, chains}
@@ -183,30 +183,30 @@ func (g *Graph) ChainOrderPathDecomp(topo []int) *Decomposition {
 	id := 0
 
 	for _, v := range topo {
-		decompNodesProcessed++
+		g.stats.DecompNodes++
 		if !used[v] {
 			// Create new chain for current vertex
 			used[v] = true
 			cNode := createListNode(createChain(id))
 
 			getEntries(cNode).Add(createListNode(v))
-			decompNodesProcessed++
+			g.stats.DecompNodes++
 			vToChain[v] = ChainMapping{cNode, 0}
 			id++
 
-			e := g.nodes[v].out
-			for e != nil {
-				decompEdgesProcessed++
-				if !used[e.target] {
+			p, end := g.outStart[v], g.outStart[v+1]
+			for p < end {
+				g.stats.DecompEdges++
+				if t := int(g.out[p]); !used[t] {
 					// Add target of edge to current chain
-					used[e.target] = true
-					vToChain[e.target] = ChainMapping{cNode, getEntries(cNode).n}
-					sNode := createListNode(e.target)
+					used[t] = true
+					vToChain[t] = ChainMapping{cNode, getEntries(cNode).n}
+					sNode := createListNode(t)
 					getEntries(cNode).Add(sNode)
-					decompNodesProcessed++
-					e = g.nodes[e.target].out
+					g.stats.DecompNodes++
+					p, end = g.outStart[t], g.outStart[t+1]
 				} else {
-					e = e.next
+					p++
 				}
 			}
 			decomp.chains.Add(cNode)
@@ -217,7 +217,7 @@ func (g *Graph) ChainOrderPathDecomp(topo []int) *Decomposition {
 
 // Creates a path decomposition using the Node-Order heuristic.
 // Runs in O(|V|+|E|).
-func (g *Graph) NodeOrderPathDecomp(topo []int) *Decomposition {
+func (g *CSRGraph) NodeOrderPathDecomp(topo []int) *Decomposition {
 	// Init empty decomposition
 	chains := createLinkedList[Chain]()
 	vToChain := make([]ChainMapping, g.n)
@@ -228,21 +228,20 @@ func (g *Graph) NodeOrderPathDecomp(topo []int) *Decomposition {
 	}
 	// Fill decomposition entries
 	for _, v := range topo {
-		decompNodesProcessed++
+		g.stats.DecompNodes++
 		used := false
-		for e := g.nodes[v].in; e != nil; e = e.next {
-			decompEdgesProcessed++
-			sChainNode := vToChain[e.source].chain
+		for _, s := range g.ins(v) {
+			g.stats.DecompEdges++
+			sChainNode := vToChain[s].chain
 			lastInSChain := getLast(sChainNode)
-			if sChainNode != nil && lastInSChain != nil && lastInSChain.val == e.source {
+			if sChainNode != nil && lastInSChain != nil && lastInSChain.val == int(s) {
 				// Source is last of its chain
 				// Insert v into chain of source
 				vToChain[v] = ChainMapping{sChainNode, getEntries(sChainNode).n}
-				tNode := createListNode(e.target)
+				tNode := createListNode(v)
 
 				getEntries(sChainNode).Add(tNode)
-				decompNodesProcessed++
-				e = g.nodes[e.target].out
+				g.stats.DecompNodes++
 				used = true
 				break
 			}
@@ -250,7 +249,7 @@ func (g *Graph) NodeOrderPathDecomp(topo []int) *Decomposition {
 		if !used {
 			// Create new chain
 			addToNewChain(id, v, decomp)
-			decompNodesProcessed++
+			g.stats.DecompNodes++
 			id++
 		}
 	}
@@ -261,7 +260,7 @@ func (g *Graph) NodeOrderPathDecomp(topo []int) *Decomposition {
 // to another chain in the decomposition.
 // Runs in O(|E| + l * (k_p - k_c)) by using the improved reversed DFS function
 // and instant conatenation.
-func (decomp *Decomposition) Concat(g *Graph) {
+func (decomp *Decomposition) Concat(g *CSRGraph) {
 	visited := make([]bool, g.n)
 
 	pNode := decomp.chains.first
@@ -283,7 +282,7 @@ func (decomp *Decomposition) Concat(g *Graph) {
 
 // H3-Conc. heuristic that uses the improved reversed DFS function.
 // Runs in O(|E| + l * (k_p - k_c)).
-func (g *Graph) HthreeConcat(topo []int) *Decomposition {
+func (g *CSRGraph) HthreeConcat(topo []int) *Decomposition {
 	chains := createLinkedList[Chain]()
 	vToChain := make([]ChainMapping, g.n)
 	decomp := &Decomposition{vToChain, chains}
@@ -295,7 +294,7 @@ func (g *Graph) HthreeConcat(topo []int) *Decomposition {
 	}
 
 	for _, v := range topo {
-		decompNodesProcessed++
+		g.stats.DecompNodes++
 		if decomp.vToChain[v].chain == nil {
 			// v not assigned to a chain
 			w := findLastOfChainMinOutdegPre(v, g, visited, decomp)
@@ -307,11 +306,11 @@ func (g *Graph) HthreeConcat(topo []int) *Decomposition {
 				vToChain[v] = ChainMapping{chainOfW, getEntries(chainOfW).n}
 				vNode := createListNode(v)
 				getEntries(chainOfW).Add(vNode)
-				decompNodesProcessed++
+				g.stats.DecompNodes++
 			} else {
 				// Create new chain
 				addToNewChain(id, v, decomp)
-				decompNodesProcessed++
+				g.stats.DecompNodes++
 				id++
 			}
 		}
@@ -322,7 +321,7 @@ func (g *Graph) HthreeConcat(topo []int) *Decomposition {
 			vToChain[t] = ChainMapping{chainOfV, getEntries(chainOfV).n}
 			tNode := createListNode(t)
 			getEntries(chainOfV).Add(tNode)
-			decompNodesProcessed++
+			g.stats.DecompNodes++
 		}
 	}
 	return decomp
@@ -1,11 +1,11 @@
-package main
+package fruit
 
 // Runs DFS only from the given start vertex s and check whether it can reach t.
 // Runs in O(|E|).
 func (g *Graph) runDFS(s, t int, visited []bool, stack, head *Stack[int]) []int {
 	stack.Push(s)
 	for !stack.IsEmpty() {
-		u := stack.Peek()
+		u, _ := stack.Peek()
 
 		if !visited[u] {
 			visited[u] = true
@@ -18,7 +18,7 @@ func (g *Graph) runDFS(s, t int, visited []bool, stack, head *Stack[int]) []int
 					stack.Push(e.target)
 				}
 			}
-		} else if head.Peek() == u {
+		} else if top, err := head.Peek(); err == nil && top == u {
 			// Backtracking
 			head.Pop()
 			stack.Pop()
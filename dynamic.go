@@ -0,0 +1,718 @@
+package fruit
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// Scheme of one direction of the index together with the positions it is
+// indexed by. The reverse scheme counts positions from the end of the chains.
+type direction struct {
+	scheme Scheme
+	pos    func(c int) int
+	// Call f for the components with an edge from or to c in this direction
+	next func(c int, f func(d int))
+	prev func(c int, f func(d int))
+}
+
+func (idx *Index) forward() direction {
+	return direction{
+		idx.scheme,
+		func(c int) int { return idx.posOf[c] },
+		idx.eachOutComp,
+		idx.eachInComp,
+	}
+}
+
+func (idx *Index) backward() direction {
+	return direction{
+		idx.reverse,
+		func(c int) int { return len(idx.chainComps[idx.chainOf[c]]) - 1 - idx.posOf[c] },
+		idx.eachInComp,
+		idx.eachOutComp,
+	}
+}
+
+// Inserts an edge from the vertex with ID u to the vertex with ID v and
+// updates the index in place. The edge is added to the graph the index
+// was built from. Only the rows of the components reaching u that did not
+// reach v before are lowered. If the edge closes a cycle, the components
+// on it are merged into one, see mergeCycle.
+// The index must not be queried while an edge is inserted.
+// Returns ErrNoGraph for an index without graph.
+func (idx *Index) InsertEdge(u, v int) error {
+	if idx.src == nil {
+		return ErrNoGraph
+	}
+	s, err := idx.vertex(u)
+	if err != nil {
+		return err
+	}
+	t, err := idx.vertex(v)
+	if err != nil {
+		return err
+	}
+	idx.insertEdge(s, t)
+	return nil
+}
+
+// Inserts an edge from vertex s to vertex t.
+func (idx *Index) insertEdge(s, t int) {
+	idx.buildTables()
+	idx.src.AddEdge(&Edge{source: s, target: t})
+	idx.m++
+	idx.stats.Edges++
+
+	a, b := idx.vToComp[s], idx.vToComp[t]
+	switch {
+	case idx.compReaches(a, b):
+		// Nothing new is reachable, including edges inside a component
+	case idx.compReaches(b, a):
+		idx.mergeCycle(a, b)
+	default:
+		idx.propagateInsert(idx.forward(), a, b)
+		if idx.reverse != nil {
+			idx.propagateInsert(idx.backward(), b, a)
+		}
+		idx.stats.SchemeEntries = idx.scheme.Entries()
+	}
+}
+
+// Lowers the rows of component a and of every component reaching it in
+// direction dir by the row of component b after an edge from a to b was added.
+// Components that already reach b keep their rows and end the search,
+// so it runs in O(k * (number of changed rows) + their in-degrees).
+func (idx *Index) propagateInsert(dir direction, a, b int) {
+	chain, pos := idx.chainOf[b], dir.pos(b)
+	queue := []int{a}
+	dir.scheme.mergeRow(a, b)
+	dir.scheme.lower(a, chain, pos)
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		dir.prev(c, func(d int) {
+			if dir.scheme.Get(d, chain) > pos {
+				dir.scheme.mergeRow(d, b)
+				dir.scheme.lower(d, chain, pos)
+				queue = append(queue, d)
+			}
+		})
+	}
+}
+
+// Merges the components on the cycles closed by a new edge from component a
+// to component b into a, found by querying which components b reaches that
+// reach a. The merged components are removed from their chains, so entries
+// pointing to them move on to the next component of the chain, and the last
+// components take over their IDs. Then the rows of the components reaching
+// a are lowered by the row of the merged component. Runs in O(|V_c| * k)
+// for the components V_c of the index without recomputing them.
+func (idx *Index) mergeCycle(a, b int) {
+	idx.normalize()
+	merged := idx.cycleComps(a, b)
+	idx.setMergedRow(idx.forward(), a, merged)
+	if idx.reverse != nil {
+		idx.setMergedRow(idx.backward(), a, merged)
+	}
+	removed := make(map[int]bool, len(merged))
+	for _, c := range merged {
+		removed[c] = c != a
+	}
+	idx.removeFromChains(removed)
+	idx.setOwnEntry(idx.forward(), a)
+	if idx.reverse != nil {
+		idx.setOwnEntry(idx.backward(), a)
+	}
+	a = idx.removeComps(a, merged)
+	idx.computeChainSizes()
+
+	idx.propagateRow(idx.forward(), a)
+	if idx.reverse != nil {
+		idx.propagateRow(idx.backward(), a)
+	}
+	idx.stats.SCCs = len(idx.chainOf)
+	idx.stats.Chains = idx.scheme.Chains()
+	idx.stats.SchemeEntries = idx.scheme.Entries()
+}
+
+// Returns the components that are reachable from component b and reach
+// component a, which lie on a cycle once a has an edge to b.
+func (idx *Index) cycleComps(a, b int) []int {
+	found := map[int]bool{b: true}
+	queue := []int{b}
+	for i := 0; i < len(queue); i++ {
+		idx.eachOutComp(queue[i], func(d int) {
+			if !found[d] && idx.compReaches(d, a) {
+				found[d] = true
+				queue = append(queue, d)
+			}
+		})
+	}
+	return queue
+}
+
+// Sets the row of component r to the minimum of the rows and positions
+// of the given components in direction dir.
+func (idx *Index) setMergedRow(dir direction, r int, merged []int) {
+	row := getRow(dir.scheme, r, nil)
+	for _, c := range merged {
+		if c != r {
+			row[idx.chainOf[c]] = min(row[idx.chainOf[c]], dir.pos(c))
+		}
+		dir.scheme.eachEntry(c, func(chain, pos int) {
+			row[chain] = min(row[chain], pos)
+		})
+	}
+	dir.scheme.setRow(r, row)
+}
+
+// Sets the entry of component r for its own chain to the position after r
+// in direction dir, which r reaches.
+func (idx *Index) setOwnEntry(dir direction, r int) {
+	row := getRow(dir.scheme, r, nil)
+	chain := idx.chainOf[r]
+	row[chain] = math.MaxInt
+	if pos := dir.pos(r) + 1; pos < len(idx.chainComps[chain]) {
+		row[chain] = pos
+	}
+	dir.scheme.setRow(r, row)
+}
+
+// Removes the components marked in removed from their chains and moves the
+// entries of all rows to the new positions. Chains left empty are dropped.
+func (idx *Index) removeFromChains(removed map[int]bool) {
+	forward, backward := make(map[int][]int), make(map[int][]int)
+	for c, remove := range removed {
+		chain := idx.chainOf[c]
+		if _, found := forward[chain]; !remove || found {
+			continue
+		}
+		comps := idx.chainComps[chain]
+		forward[chain] = remainingPositions(comps, removed)
+		reversed := slices.Clone(comps)
+		slices.Reverse(reversed)
+		backward[chain] = remainingPositions(reversed, removed)
+	}
+	remapRows(idx.scheme, forward)
+	if idx.reverse != nil {
+		remapRows(idx.reverse, backward)
+	}
+	for chain := range forward {
+		comps := idx.chainComps[chain][:0]
+		for _, c := range idx.chainComps[chain] {
+			if !removed[c] {
+				idx.posOf[c] = len(comps)
+				comps = append(comps, c)
+			}
+		}
+		idx.chainComps[chain] = comps
+	}
+	for chain := len(idx.chainComps) - 1; chain >= 0; chain-- {
+		if len(idx.chainComps[chain]) == 0 {
+			idx.removeChain(chain)
+		}
+	}
+}
+
+// Returns the new position of the first remaining component at or after
+// each position of the chain, or math.MaxInt if all later ones are removed.
+func remainingPositions(comps []int, removed map[int]bool) []int {
+	positions := make([]int, len(comps))
+	remaining := 0
+	for i, c := range comps {
+		positions[i] = remaining
+		if !removed[c] {
+			remaining++
+		}
+	}
+	for i := len(comps) - 1; i >= 0 && removed[comps[i]]; i-- {
+		positions[i] = math.MaxInt
+	}
+	return positions
+}
+
+// Replaces the entries of all rows of s for the given chains by their new positions.
+func remapRows(s Scheme, positions map[int][]int) {
+	var row []int
+	for v := 0; v < s.Rows(); v++ {
+		affected := false
+		s.eachEntry(v, func(c, pos int) {
+			_, found := positions[c]
+			affected = affected || found
+		})
+		if !affected {
+			continue
+		}
+		row = getRow(s, v, row)
+		for c, newPos := range positions {
+			if !isInfinite(row[c]) {
+				row[c] = newPos[row[c]]
+			}
+		}
+		s.setRow(v, row)
+	}
+}
+
+// Removes an empty chain by moving the last chain to its ID.
+func (idx *Index) removeChain(chain int) {
+	last := len(idx.chainComps) - 1
+	for _, c := range idx.chainComps[last] {
+		idx.chainOf[c] = chain
+	}
+	idx.chainComps[chain] = idx.chainComps[last]
+	idx.chainComps = idx.chainComps[:last]
+	idx.chainBase[chain] = idx.chainBase[last]
+	idx.chainBase = idx.chainBase[:last]
+	idx.scheme = removeColumn(idx.scheme, chain)
+	if idx.reverse != nil {
+		idx.reverse = removeColumn(idx.reverse, chain)
+	}
+}
+
+// Returns s without the entries of chain c. The last chain takes its place.
+func removeColumn(s Scheme, c int) Scheme {
+	last := s.Chains() - 1
+	var row []int
+	for v := 0; v < s.Rows(); v++ {
+		row = getRow(s, v, row)
+		row[c] = row[last]
+		s.setRow(v, row)
+	}
+	return resizeScheme(s, s.Rows(), last, s.Width())
+}
+
+// Removes the merged components other than r, whose vertices move to r.
+// The last components are moved to the free IDs. Returns the new ID of r.
+func (idx *Index) removeComps(r int, merged []int) int {
+	var removed []int
+	for _, c := range merged {
+		if c != r {
+			removed = append(removed, c)
+			for _, v := range idx.members[c] {
+				idx.vToComp[v] = r
+			}
+			idx.members[r] = append(idx.members[r], idx.members[c]...)
+		}
+	}
+	// Removing the highest IDs first never moves a removed component
+	sort.Sort(sort.Reverse(sort.IntSlice(removed)))
+	for _, c := range removed {
+		last := len(idx.chainOf) - 1
+		if c != last {
+			idx.moveComp(last, c)
+			if r == last {
+				r = c
+			}
+		}
+		idx.chainOf = idx.chainOf[:last]
+		idx.posOf = idx.posOf[:last]
+		idx.members = idx.members[:last]
+	}
+	idx.scheme = resizeScheme(idx.scheme, len(idx.chainOf), idx.scheme.Chains(), idx.scheme.Width())
+	if idx.reverse != nil {
+		idx.reverse = resizeScheme(idx.reverse, len(idx.chainOf), idx.reverse.Chains(), idx.reverse.Width())
+	}
+	return r
+}
+
+// Moves component from to the unused ID to.
+func (idx *Index) moveComp(from, to int) {
+	idx.chainOf[to], idx.posOf[to] = idx.chainOf[from], idx.posOf[from]
+	idx.members[to] = idx.members[from]
+	for _, v := range idx.members[to] {
+		idx.vToComp[v] = to
+	}
+	idx.chainComps[idx.chainOf[to]][idx.posOf[to]] = to
+	idx.scheme.setRow(to, getRow(idx.scheme, from, nil))
+	if idx.reverse != nil {
+		idx.reverse.setRow(to, getRow(idx.reverse, from, nil))
+	}
+}
+
+// Lowers the rows of the components reaching component r in direction dir
+// by the row and position of r, as long as this changes their rows.
+func (idx *Index) propagateRow(dir direction, r int) {
+	chain, pos := idx.chainOf[r], dir.pos(r)
+	var old, row []int
+	queue := []int{r}
+	for i := 0; i < len(queue); i++ {
+		dir.prev(queue[i], func(d int) {
+			old = getRow(dir.scheme, d, old)
+			dir.scheme.mergeRow(d, r)
+			dir.scheme.lower(d, chain, pos)
+			if row = getRow(dir.scheme, d, row); !slices.Equal(old, row) {
+				queue = append(queue, d)
+			}
+		})
+	}
+}
+
+// Adds a vertex with the given ID and edges to the vertices with the given
+// IDs, which must exist, to the graph the index was built from and to the
+// index. The row of the new vertex is the minimum of the rows and positions
+// of its successors. It is put in front of a chain whose first vertex it
+// reaches or otherwise into a new chain, so adding vertices that point at
+// the latest ones of a growing history rarely starts new chains.
+// Runs in O(len(successors) * k) without changing the rows of other vertices,
+// except when the free positions in front of the chain run out. Then all
+// rows reaching the chain are moved, which leaves as many free positions
+// as the chain has vertices. The reverse scheme, if created, is updated
+// for the vertices the new one reaches.
+// Returns ErrNoGraph for an index without graph.
+func (idx *Index) AppendVertex(id int, successors ...int) error {
+	if idx.src == nil {
+		return ErrNoGraph
+	}
+	if _, found := idx.Vertex(id); found {
+		return &DuplicateVertexError{id}
+	}
+	targets, err := idx.vertices(successors)
+	if err != nil {
+		return err
+	}
+	idx.buildTables()
+	w := idx.src.addVertex(id)
+	for _, t := range targets {
+		idx.src.AddEdge(&Edge{source: w, target: t})
+	}
+	idx.n++
+	idx.m += len(targets)
+	idx.stats.Nodes++
+	idx.stats.Edges += len(targets)
+
+	c := len(idx.chainOf)
+	idx.vToComp = append(idx.vToComp, c)
+	idx.members = append(idx.members, []int{w})
+	idx.chainOf = append(idx.chainOf, 0)
+	idx.posOf = append(idx.posOf, 0)
+	idx.scheme.grow(1, 0)
+	if idx.reverse != nil {
+		idx.reverse.grow(1, 0)
+	}
+	row := make([]int, idx.scheme.Chains())
+	for chain := range row {
+		row[chain] = math.MaxInt
+	}
+	for _, t := range targets {
+		d := idx.vToComp[t]
+		row[idx.chainOf[d]] = min(row[idx.chainOf[d]], idx.posOf[d])
+		idx.scheme.eachEntry(d, func(chain, pos int) {
+			row[chain] = min(row[chain], pos)
+		})
+	}
+
+	// A chain whose first component the new one reaches
+	chain := -1
+	for i, pos := range row {
+		if pos == idx.chainBase[i] {
+			chain = i
+			break
+		}
+	}
+	if chain >= 0 {
+		if idx.chainBase[chain] == 0 {
+			delta := len(idx.chainComps[chain])
+			idx.rebase(chain, delta)
+			row[chain] += delta
+		}
+		idx.prepend(c, chain)
+	} else {
+		idx.addChain(c)
+		row = append(row, math.MaxInt)
+	}
+	idx.scheme.setRow(c, row)
+
+	if idx.reverse != nil {
+		idx.propagateRow(idx.backward(), c)
+	}
+	idx.stats.SCCs++
+	idx.stats.SchemeEntries = idx.scheme.Entries()
+	return nil
+}
+
+// Puts component c in front of the chain, which needs a free position.
+func (idx *Index) prepend(c, chain int) {
+	pos := idx.chainBase[chain] - 1
+	idx.chainBase[chain] = pos
+	idx.chainComps[chain][pos] = c
+	idx.chainOf[c], idx.posOf[c] = chain, pos
+	sizes := idx.chainSizes[chain]
+	sizes[pos] = sizes[pos+1] - len(idx.members[c])
+	length := len(idx.chainComps[chain]) - pos
+	if idx.reverse != nil && schemeWidthFor(length) > idx.reverse.Width() {
+		idx.reverse = resizeScheme(idx.reverse, idx.reverse.Rows(), idx.reverse.Chains(), schemeWidthFor(length))
+	}
+}
+
+// Puts component c into a new chain.
+func (idx *Index) addChain(c int) {
+	idx.scheme.grow(0, 1)
+	if idx.reverse != nil {
+		idx.reverse.grow(0, 1)
+	}
+	idx.chainOf[c], idx.posOf[c] = len(idx.chainComps), 0
+	idx.chainComps = append(idx.chainComps, []int{c})
+	idx.chainSizes = append(idx.chainSizes, []int{0, len(idx.members[c])})
+	idx.chainBase = append(idx.chainBase, 0)
+	idx.stats.Chains++
+}
+
+// Moves the positions of the chain and the entries of all rows for it by
+// delta. A positive delta leaves free positions in front of the chain.
+// The reverse scheme counts positions from the end and does not change.
+func (idx *Index) rebase(chain, delta int) {
+	comps, sizes := idx.chainComps[chain], idx.chainSizes[chain]
+	if width := schemeWidthFor(len(comps) + delta); width > idx.scheme.Width() {
+		idx.scheme = resizeScheme(idx.scheme, idx.scheme.Rows(), idx.scheme.Chains(), width)
+		idx.stats.SchemeWidth = width
+	}
+	var row []int
+	for c := 0; c < idx.scheme.Rows(); c++ {
+		if !isInfinite(idx.scheme.Get(c, chain)) {
+			row = getRow(idx.scheme, c, row)
+			row[chain] += delta
+			idx.scheme.setRow(c, row)
+		}
+	}
+	if delta > 0 {
+		free := make([]int, delta)
+		for i := range free {
+			free[i] = -1
+		}
+		comps = append(free, comps...)
+		sizes = append(make([]int, delta), sizes...)
+	} else {
+		comps, sizes = comps[-delta:], sizes[-delta:]
+	}
+	idx.chainComps[chain], idx.chainSizes[chain] = comps, sizes
+	idx.chainBase[chain] += delta
+	for _, c := range comps[idx.chainBase[chain]:] {
+		idx.posOf[c] += delta
+	}
+}
+
+// Drops the free positions in front of all chains.
+func (idx *Index) normalize() {
+	for chain, base := range idx.chainBase {
+		if base > 0 {
+			idx.rebase(chain, -base)
+		}
+	}
+}
+
+// Deletes the edge from the vertex with ID u to the vertex with ID v and
+// updates the index in place. The edge is removed from the graph the index
+// was built from. If it was the last edge between two components, the rows
+// of the components reaching u are recomputed from their successors and
+// chains whose consecutive components no longer reach each other are split.
+// Only a component that falls apart into several is handled by rebuilding.
+// The index must not be queried while an edge is deleted.
+// Returns ErrNoGraph for an index without graph.
+func (idx *Index) DeleteEdge(u, v int) error {
+	if idx.src == nil {
+		return ErrNoGraph
+	}
+	s, err := idx.vertex(u)
+	if err != nil {
+		return err
+	}
+	t, err := idx.vertex(v)
+	if err != nil {
+		return err
+	}
+	for e := idx.src.nodes[s].out; e != nil; e = e.next {
+		if e.target == t {
+			return idx.deleteEdge(e)
+		}
+	}
+	return &UnknownEdgeError{u, v}
+}
+
+// Deletes edge e of the graph the index was built from.
+func (idx *Index) deleteEdge(e *Edge) error {
+	idx.buildTables()
+	unlink(idx.src, e, true)
+	idx.m--
+	idx.stats.Edges--
+
+	a, b := idx.vToComp[e.source], idx.vToComp[e.target]
+	switch {
+	case a == b:
+		if idx.pathInComp(e.source, e.target) == nil {
+			return idx.rebuild()
+		}
+	case idx.hasCompEdge(a, b):
+		// Another edge keeps b reachable from a
+	default:
+		idx.repairDeletion(a, b)
+		idx.stats.SchemeEntries = idx.scheme.Entries()
+	}
+	return nil
+}
+
+// Returns whether the graph has an edge from component a to component b.
+func (idx *Index) hasCompEdge(a, b int) bool {
+	found := false
+	idx.eachOutComp(a, func(d int) {
+		found = found || d == b
+	})
+	return found
+}
+
+// Repairs the index after the last edge from component a to component b was deleted.
+func (idx *Index) repairDeletion(a, b int) {
+	ancestors := idx.reachingOrder(idx.forward(), a)
+	var broken []int
+	for _, c := range idx.recomputeRows(idx.forward(), ancestors, true) {
+		chain, pos := idx.chainOf[c], idx.posOf[c]
+		if pos+1 < len(idx.chainComps[chain]) && idx.scheme.Get(c, chain) != pos+1 {
+			broken = append(broken, c)
+		}
+	}
+	if len(broken) == 0 {
+		if idx.reverse != nil {
+			idx.recomputeRows(idx.backward(), idx.reachingOrder(idx.backward(), b), true)
+		}
+		return
+	}
+	// Split later links first, so the positions of earlier ones stay valid
+	sort.Slice(broken, func(i, j int) bool {
+		return idx.posOf[broken[i]] > idx.posOf[broken[j]]
+	})
+	for _, c := range broken {
+		idx.splitChain(idx.chainOf[c], idx.posOf[c]+1)
+	}
+	idx.recomputeRows(idx.forward(), ancestors, false)
+	if idx.reverse != nil {
+		idx.CreateReverse()
+	}
+}
+
+// Returns component a and the components reaching it in direction dir,
+// each after all of its successors among them.
+func (idx *Index) reachingOrder(dir direction, a int) []int {
+	// Number of edges to unordered components, for every component reaching a
+	pending := map[int]int{a: 0}
+	queue := []int{a}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		dir.prev(c, func(d int) {
+			if _, found := pending[d]; !found {
+				pending[d] = 0
+				queue = append(queue, d)
+			}
+			pending[d]++
+		})
+	}
+	order := []int{a}
+	for i := 0; i < len(order); i++ {
+		dir.prev(order[i], func(d int) {
+			if pending[d]--; pending[d] == 0 {
+				order = append(order, d)
+			}
+		})
+	}
+	return order
+}
+
+// Recomputes the rows of the components in order from their successors in
+// direction dir in O(k * sum of their out-degrees). With prune, only the
+// first component and those with a changed successor are recomputed.
+// Returns the components whose rows changed.
+func (idx *Index) recomputeRows(dir direction, order []int, prune bool) []int {
+	k := dir.scheme.Chains()
+	row := make([]int, k)
+	var old []int
+	changed := make(map[int]bool)
+	var changedComps []int
+	for i, c := range order {
+		if prune && i > 0 {
+			dirty := false
+			dir.next(c, func(d int) {
+				dirty = dirty || changed[d]
+			})
+			if !dirty {
+				continue
+			}
+		}
+		for chain := range row {
+			row[chain] = math.MaxInt
+		}
+		dir.next(c, func(d int) {
+			chain := idx.chainOf[d]
+			row[chain] = min(row[chain], dir.pos(d))
+			dir.scheme.eachEntry(d, func(chain, pos int) {
+				row[chain] = min(row[chain], pos)
+			})
+		})
+		old = getRow(dir.scheme, c, old)
+		if !slices.Equal(old, row) {
+			dir.scheme.setRow(c, row)
+			changed[c] = true
+			changedComps = append(changedComps, c)
+		}
+	}
+	return changedComps
+}
+
+// Moves the components from position p on of the chain to a new chain.
+// Rows of components whose reachability did not change stay valid,
+// the others have to be recomputed.
+func (idx *Index) splitChain(chain, p int) {
+	k := idx.scheme.Chains()
+	idx.scheme = resizeScheme(idx.scheme, idx.scheme.Rows(), k+1, idx.scheme.Width())
+	var row []int
+	for c := 0; c < idx.scheme.Rows(); c++ {
+		entry := idx.scheme.Get(c, chain)
+		if isInfinite(entry) {
+			continue
+		}
+		row = getRow(idx.scheme, c, row)
+		if entry >= p {
+			row[chain] = math.MaxInt
+			row[k] = entry - p
+		} else {
+			// c reaches the rest of the chain through the moved part
+			row[k] = 0
+		}
+		idx.scheme.setRow(c, row)
+	}
+
+	comps := idx.chainComps[chain]
+	idx.chainComps[chain] = comps[:p]
+	idx.chainComps = append(idx.chainComps, slices.Clone(comps[p:]))
+	for pos, c := range idx.chainComps[k] {
+		idx.chainOf[c] = k
+		idx.posOf[c] = pos
+	}
+	sizes := idx.chainSizes[chain]
+	moved := make([]int, len(sizes)-p)
+	for i := range moved {
+		moved[i] = sizes[p+i] - sizes[p]
+	}
+	idx.chainSizes[chain] = sizes[:p+1]
+	idx.chainSizes = append(idx.chainSizes, moved)
+	idx.chainBase = append(idx.chainBase, 0)
+	idx.stats.Chains++
+}
+
+// Rebuilds the index from the graph it was built from.
+// The reverse scheme is recreated if it existed.
+func (idx *Index) rebuild() error {
+	built, err := CreateIndex(idx.src, idx.method)
+	if err != nil {
+		return err
+	}
+	idx.n, idx.m = built.n, built.m
+	idx.vToComp, idx.chainOf, idx.posOf = built.vToComp, built.chainOf, built.posOf
+	idx.scheme = built.scheme
+	idx.stats = built.stats
+	idx.tablesOnce.Do(func() {})
+	idx.computeTables()
+	if idx.reverse != nil {
+		return idx.CreateReverse()
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package fruit
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// Compares the answers of idx with DFS on the graph it was built from.
+func checkUpdatedIndex(idx *Index, t *testing.T) {
+	t.Helper()
+	g := idx.src
+	m := g.dfsCreateMatrix()
+	if _, comps := g.FindSCCs(); len(comps) != idx.Stats().SCCs {
+		t.Fatalf("Index has %d components, graph %d", idx.Stats().SCCs, len(comps))
+	}
+	for s, sID := range g.idMapping.vToId {
+		ancestors, descendants := 0, 0
+		for w, wID := range g.idMapping.vToId {
+			if reachable, err := idx.Reachable(sID, wID); err != nil || reachable != m[s][w] {
+				t.Fatalf("Reachable(%d, %d) = %t, %v, want %t", sID, wID, reachable, err, m[s][w])
+			}
+			if m[w][s] && w != s {
+				ancestors++
+			}
+			if m[s][w] && w != s {
+				descendants++
+			}
+		}
+		if n, err := idx.ReachCount(sID); err != nil || n != descendants {
+			t.Fatalf("ReachCount(%d) = %d, %v, want %d", sID, n, err, descendants)
+		}
+		if idx.reverse == nil {
+			continue
+		}
+		if n, err := idx.AncestorCount(sID); err != nil || n != ancestors {
+			t.Fatalf("AncestorCount(%d) = %d, %v, want %d", sID, n, err, ancestors)
+		}
+	}
+}
+
+// Creates the path 1 -> 2 -> ... -> n with vertex IDs 1 to n.
+func createPathGraph(n int) *Graph {
+	g := CreateGraph(n)
+	g.idMapping = IdMapping{make(map[int]int), make(map[int]int)}
+	for v := 0; v < n; v++ {
+		g.AddVtoIdMapping(v+1, v)
+	}
+	for v := 0; v+1 < n; v++ {
+		g.AddEdge(&Edge{source: v, target: v + 1})
+	}
+	return g
+}
+
+func TestInsertEdge(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_10.gr",
+		"./data/gnm/gnm_100_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			idx := createTestIndex(g, t)
+			if err := idx.CreateReverse(); err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int, 0, len(g.idMapping.idToV))
+			for id := range g.idMapping.idToV {
+				ids = append(ids, id)
+			}
+			slices.Sort(ids)
+			rng := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 20; i++ {
+				u, v := ids[rng.Intn(len(ids))], ids[rng.Intn(len(ids))]
+				cycle, _ := idx.Reachable(v, u)
+				scheme := idx.scheme
+				if err := idx.InsertEdge(u, v); err != nil {
+					t.Fatal(err)
+				}
+				if !cycle && idx.scheme != scheme {
+					t.Fatalf("InsertEdge(%d, %d) replaced the scheme without closing a cycle", u, v)
+				}
+				if scc, err := idx.SCC(u); err != nil || cycle != slices.Contains(scc, v) {
+					t.Fatalf("SCC(%d) = %v, %v after inserting an edge to %d", u, scc, err, v)
+				}
+				if path, err := idx.Path(u, v); err != nil || path == nil {
+					t.Fatalf("Path(%d, %d) = %v, %v after inserting the edge", u, v, path, err)
+				}
+				checkUpdatedIndex(idx, t)
+			}
+			if idx.M() != g.m {
+				t.Errorf("Index has %d edges, graph %d", idx.M(), g.m)
+			}
+		})
+	}
+}
+
+func TestInsertEdgeErrors(t *testing.T) {
+	g := readTestGraph("./test_graphs/collapse.gr", t)
+	idx := createTestIndex(g, t)
+	var unknownErr *UnknownVertexError
+	if err := idx.InsertEdge(-1, 1); !errors.As(err, &unknownErr) {
+		t.Errorf("InsertEdge with unknown ID returned %v", err)
+	}
+	path := t.TempDir() + "/collapse.idx"
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.InsertEdge(1, 2); !errors.Is(err, ErrNoGraph) {
+		t.Errorf("InsertEdge on loaded index returned %v, want %v", err, ErrNoGraph)
+	}
+}
+
+func TestDeleteEdge(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gnm/gnm_100_1000.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			idx := createTestIndex(g, t)
+			if err := idx.CreateReverse(); err != nil {
+				t.Fatal(err)
+			}
+			rng := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 20 && g.m > 0; i++ {
+				// Pick a random edge by its position in the out lists
+				j := rng.Intn(g.m)
+				var e *Edge
+				for v := 0; e == nil; v++ {
+					if j < g.nodes[v].outDeg {
+						for e = g.nodes[v].out; j > 0; j-- {
+							e = e.next
+						}
+					} else {
+						j -= g.nodes[v].outDeg
+					}
+				}
+				u, v := g.idMapping.vToId[e.source], g.idMapping.vToId[e.target]
+				if err := idx.DeleteEdge(u, v); err != nil {
+					t.Fatal(err)
+				}
+				checkUpdatedIndex(idx, t)
+				if i%4 == 0 {
+					// Deleted edges can be inserted again
+					if err := idx.InsertEdge(u, v); err != nil {
+						t.Fatal(err)
+					}
+					checkUpdatedIndex(idx, t)
+				}
+			}
+			if idx.M() != g.m {
+				t.Errorf("Index has %d edges, graph %d", idx.M(), g.m)
+			}
+		})
+	}
+}
+
+func TestDeleteEdgeSplitsChain(t *testing.T) {
+	// The only chain 1 -> 2 -> 3 -> 4 loses its middle link
+	idx := createTestIndex(createPathGraph(4), t)
+	if err := idx.CreateReverse(); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.DeleteEdge(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if chains := idx.Stats().Chains; chains != 2 {
+		t.Errorf("Index has %d chains after deleting a chain link, want 2", chains)
+	}
+	checkUpdatedIndex(idx, t)
+
+	var edgeErr *UnknownEdgeError
+	if err := idx.DeleteEdge(2, 3); !errors.As(err, &edgeErr) || edgeErr.Source != 2 || edgeErr.Target != 3 {
+		t.Errorf("Deleting a missing edge returned %v", err)
+	}
+}
+
+func TestAppendVertex(t *testing.T) {
+	files := []string{
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_10.gr",
+		"./data/gnm/gnm_100_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			idx := createTestIndex(g, t)
+			if err := idx.CreateReverse(); err != nil {
+				t.Fatal(err)
+			}
+			ids := make([]int, 0, len(g.idMapping.idToV))
+			for id := range g.idMapping.idToV {
+				ids = append(ids, id)
+			}
+			slices.Sort(ids)
+			rng := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 30; i++ {
+				id := -1 - i
+				successors := make([]int, rng.Intn(4))
+				for j := range successors {
+					successors[j] = ids[rng.Intn(len(ids))]
+				}
+				if err := idx.AppendVertex(id, successors...); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			checkUpdatedIndex(idx, t)
+
+			// Appended vertices take part in later updates and are saved
+			if err := idx.InsertEdge(ids[0], ids[len(ids)-1]); err != nil {
+				t.Fatal(err)
+			}
+			checkUpdatedIndex(idx, t)
+			path := t.TempDir() + "/appended.idx"
+			if err := idx.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !compQuadraticMatrices(idx.Matrix(), loaded.Matrix()) {
+				t.Error("Loaded index answers differently than the updated one")
+			}
+		})
+	}
+}
+
+func TestAppendVertexHistory(t *testing.T) {
+	// A history where every vertex points at the previous one and some at an older one
+	idx := createTestIndex(createPathGraph(1), t)
+	if err := idx.CreateReverse(); err != nil {
+		t.Fatal(err)
+	}
+	for id := 2; id <= 300; id++ {
+		successors := []int{id - 1}
+		if id%10 == 0 {
+			successors = append(successors, id-7)
+		}
+		if err := idx.AppendVertex(id, successors...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := idx.Stats(); stats.Chains != 1 || stats.SchemeWidth != 2 {
+		t.Errorf("History is indexed with %d chains of width %d, want 1 chain of width 2", stats.Chains, stats.SchemeWidth)
+	}
+	checkUpdatedIndex(idx, t)
+	chain, pos, err := idx.Chain(300)
+	if err != nil || len(chain) != 300 || pos != 0 || chain[0][0] != 300 || chain[299][0] != 1 {
+		t.Errorf("Chain(300) = %d components, position %d, %v", len(chain), pos, err)
+	}
+}
+
+func TestAppendVertexErrors(t *testing.T) {
+	idx := createTestIndex(createPathGraph(3), t)
+	var duplicateErr *DuplicateVertexError
+	if err := idx.AppendVertex(2); !errors.As(err, &duplicateErr) || duplicateErr.ID != 2 {
+		t.Errorf("AppendVertex with existing ID returned %v", err)
+	}
+	var unknownErr *UnknownVertexError
+	if err := idx.AppendVertex(4, 1, 5); !errors.As(err, &unknownErr) || unknownErr.ID != 5 {
+		t.Errorf("AppendVertex with unknown successor returned %v", err)
+	}
+	if idx.N() != 3 {
+		t.Errorf("Failed appends changed the index to %d vertices", idx.N())
+	}
+}
+
+func TestSparseIndexUpdates(t *testing.T) {
+	g := readTestGraph("./data/gn/gn_100.gr", t)
+	idx := createTestIndex(g, t)
+	if !idx.scheme.Sparse() {
+		t.Fatal("Index of a tree has a dense scheme")
+	}
+	stored := idx.scheme.(interface{ bytes() int })
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		// Insert an edge that lowers the rows of the ancestors of u and delete it again
+		u, v := g.idMapping.vToId[rng.Intn(g.n)], g.idMapping.vToId[rng.Intn(g.n)]
+		if reachable, _ := idx.Reachable(v, u); reachable {
+			continue
+		}
+		if err := idx.InsertEdge(u, v); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.DeleteEdge(u, v); err != nil {
+			t.Fatal(err)
+		}
+		// At most half of the stored entries and row offsets are unused
+		live := idx.scheme.Entries()*(4+idx.scheme.Width()) + idx.scheme.Rows()*16
+		if 2*stored.bytes() > 3*live {
+			t.Fatalf("Scheme uses %d bytes for %d entries after %d updates", stored.bytes(), idx.scheme.Entries(), i+1)
+		}
+	}
+	checkUpdatedIndex(idx, t)
+}
@@ -0,0 +1,82 @@
+package fruit
+
+// Calls f with the ID of every vertex other than the vertex with ID v that
+// it can reach, in no particular order, until f returns false.
+// Reads the descendants straight from the chains: a component reaches every
+// component in chain c from position scheme[v][c] on.
+// Runs in O(k + number of descendants) for a dense and O(finite entries of v
+// + number of descendants) for a sparse scheme.
+func (idx *Index) Descendants(v int, f func(w int) bool) error {
+	u, err := idx.vertex(v)
+	if err != nil {
+		return err
+	}
+	idx.descendants(u, idx.withIDs(f))
+	return nil
+}
+
+// Calls f for every vertex other than v that v can reach until f returns false.
+func (idx *Index) descendants(v int, f func(w int) bool) {
+	idx.buildTables()
+	comp := idx.vToComp[v]
+	if !idx.eachMember(comp, v, f) {
+		return
+	}
+	done := false
+	idx.scheme.eachEntry(comp, func(chain, pos int) {
+		for _, c := range idx.chainComps[chain][min(pos, len(idx.chainComps[chain])):] {
+			if done || !idx.eachMember(c, v, f) {
+				done = true
+				return
+			}
+		}
+	})
+}
+
+// Calls f for the vertices of component c other than v.
+// Returns false if f returned false.
+func (idx *Index) eachMember(c, v int, f func(w int) bool) bool {
+	for _, w := range idx.members[c] {
+		if w != v && !f(w) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the IDs of the strongly connected component of the vertex with ID v.
+func (idx *Index) SCC(v int) ([]int, error) {
+	u, err := idx.vertex(v)
+	if err != nil {
+		return nil, err
+	}
+	idx.buildTables()
+	return idx.memberIDs(idx.vToComp[u]), nil
+}
+
+// Returns the chain containing the component of the vertex with ID v as the
+// IDs of every component in chain order and the position of v in the chain.
+func (idx *Index) Chain(v int) ([][]int, int, error) {
+	u, err := idx.vertex(v)
+	if err != nil {
+		return nil, 0, err
+	}
+	idx.buildTables()
+	comp := idx.vToComp[u]
+	base := idx.chainBase[idx.chainOf[comp]]
+	comps := idx.chainComps[idx.chainOf[comp]][base:]
+	chain := make([][]int, len(comps))
+	for i, c := range comps {
+		chain[i] = idx.memberIDs(c)
+	}
+	return chain, idx.posOf[comp] - base, nil
+}
+
+// Returns the IDs of the vertices of component c.
+func (idx *Index) memberIDs(c int) []int {
+	ids := make([]int, len(idx.members[c]))
+	for i, w := range idx.members[c] {
+		ids[i], _ = idx.ID(w)
+	}
+	return ids
+}
@@ -0,0 +1,208 @@
+package fruit
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDescendants(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gnm/gnm_100_1000.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			m := g.dfsCreateMatrix()
+			idx := createTestIndex(g, t)
+
+			for v, id := range g.idMapping.vToId {
+				found := make([]bool, idx.N())
+				err := idx.Descendants(id, func(wID int) bool {
+					w := g.idMapping.idToV[wID]
+					if found[w] {
+						t.Fatalf("Descendants(%d) lists %d twice", id, wID)
+					}
+					found[w] = true
+					return true
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for w := range found {
+					if found[w] != (m[v][w] && w != v) {
+						t.Fatalf("Descendants(%d) lists vertex %d: %t, reachable %t", id, w, found[w], m[v][w])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDescendantsStop(t *testing.T) {
+	g := readTestGraph("./data/gnm/gnm_100_1000.gr", t)
+	idx := createTestIndex(g, t)
+	calls := 0
+	idx.Descendants(1, func(w int) bool {
+		calls++
+		return calls < 3
+	})
+	if calls != 3 {
+		t.Errorf("Descendants called f %d times after it returned false, want 3", calls)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gnm/gnm_100_1000.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			m := g.dfsCreateMatrix()
+			idx := createTestIndex(g, t)
+			if err := idx.Ancestors(1, func(int) bool { return true }); !errors.Is(err, ErrNoReverse) {
+				t.Fatalf("Ancestors without reverse scheme returned %v, want %v", err, ErrNoReverse)
+			}
+			// Indexes without graph derive the reverse scheme from their own
+			path := filepath.Join(t.TempDir(), "index.fruit")
+			if err := idx.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mapped, err := OpenMapped(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer mapped.Close()
+
+			for name, idx := range map[string]*Index{"built": idx, "loaded": loaded, "mapped": mapped} {
+				if err := idx.CreateReverse(); err != nil {
+					t.Fatal(err)
+				}
+				for v, id := range g.idMapping.vToId {
+					found := make([]bool, idx.N())
+					count := 0
+					err := idx.Ancestors(id, func(uID int) bool {
+						u := g.idMapping.idToV[uID]
+						if found[u] {
+							t.Fatalf("Ancestors(%d) of %s index lists %d twice", id, name, uID)
+						}
+						found[u] = true
+						count++
+						return true
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+					for u := range found {
+						if found[u] != (m[u][v] && u != v) {
+							t.Fatalf("Ancestors(%d) of %s index lists vertex %d: %t, reachable %t", id, name, u, found[u], m[u][v])
+						}
+					}
+					if n, err := idx.AncestorCount(id); err != nil || n != count {
+						t.Fatalf("AncestorCount(%d) of %s index = %d, %v, want %d", id, name, n, err, count)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReachCount(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gnm/gnm_100_1000.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			m := g.dfsCreateMatrix()
+			idx := createTestIndex(g, t)
+
+			counts := make([]int, idx.N())
+			closure := 0
+			for s := range m {
+				for w := range m[s] {
+					if m[s][w] && s != w {
+						counts[s]++
+						closure++
+					}
+				}
+				if id, ok := g.idMapping.vToId[s]; ok {
+					if got, err := idx.ReachCount(id); err != nil || got != counts[s] {
+						t.Fatalf("ReachCount(%d) = %d, %v, want %d", id, got, err, counts[s])
+					}
+				}
+			}
+			if got := idx.ClosureSize(); got != closure {
+				t.Errorf("ClosureSize() = %d, want %d", got, closure)
+			}
+			top := idx.TopReachers(10)
+			if len(top) != min(10, len(g.idMapping.vToId)) {
+				t.Fatalf("TopReachers(10) returned %d vertices", len(top))
+			}
+			for i, id := range top {
+				top[i] = g.idMapping.idToV[id]
+			}
+			for i := 1; i < len(top); i++ {
+				if counts[top[i-1]] < counts[top[i]] {
+					t.Fatalf("TopReachers(10) = %v is not ordered by reach", top)
+				}
+			}
+			for v := range g.idMapping.vToId {
+				if counts[v] > counts[top[len(top)-1]] && !slices.Contains(top, v) {
+					t.Fatalf("TopReachers(10) = %v misses %d reaching %d vertices", top, v, counts[v])
+				}
+			}
+		})
+	}
+}
+
+func TestSCCAndChain(t *testing.T) {
+	g := readTestGraph("./test_graphs/collapse.gr", t)
+	m := g.dfsCreateMatrix()
+	idx := createTestIndex(g, t)
+
+	for v, id := range g.idMapping.vToId {
+		scc, err := idx.SCC(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for w, wID := range g.idMapping.vToId {
+			if slices.Contains(scc, wID) != (m[v][w] && m[w][v]) {
+				t.Fatalf("SCC(%d) = %v, contains %d: %t", id, scc, wID, !(m[v][w] && m[w][v]))
+			}
+		}
+
+		chain, pos, err := idx.Chain(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(chain[pos], id) {
+			t.Fatalf("Chain(%d) = %v, %d does not contain it at its position", id, chain, pos)
+		}
+		for i := 0; i+1 < len(chain); i++ {
+			if reachable, _ := idx.Reachable(chain[i][0], chain[i+1][0]); !reachable {
+				t.Fatalf("Chain(%d) = %v is not a chain", id, chain)
+			}
+		}
+	}
+}
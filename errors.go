@@ -0,0 +1,101 @@
+package fruit
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrEmptyStack = errors.New("stack is empty")
+	ErrEmptyList  = errors.New("linked list is empty")
+	ErrNilNode    = errors.New("list node is nil")
+	ErrHeader     = errors.New("malformed header, expected \"n: <number of vertices>\"")
+	ErrGraphSize  = errors.New("graph has more than 2^31-1 vertices")
+)
+
+// ParseError reports a line of a graph or query file that could not be parsed.
+type ParseError struct {
+	Path string
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: cannot parse %q: %v", e.Path, e.Line, e.Text, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// QueryError reports a query of a batch file that could not be answered.
+type QueryError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError reports the queries of a batch that could not be answered.
+// Errs holds a QueryError for each of them.
+type BatchError struct {
+	Path string
+	Errs []error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s: %d failed queries, the first: %v", e.Path, len(e.Errs), e.Errs[0])
+}
+
+func (e *BatchError) Unwrap() []error {
+	return e.Errs
+}
+
+// VertexRangeError reports a graph file that uses more distinct vertices
+// than announced in its header.
+type VertexRangeError struct {
+	Path string
+	Line int
+	ID   int
+	N    int
+}
+
+func (e *VertexRangeError) Error() string {
+	return fmt.Sprintf("%s:%d: vertex %d exceeds the %d vertices given in the header", e.Path, e.Line, e.ID, e.N)
+}
+
+// UnknownVertexError reports a vertex ID that does not occur in the indexed graph.
+type UnknownVertexError struct {
+	ID int
+}
+
+func (e *UnknownVertexError) Error() string {
+	return fmt.Sprintf("unknown vertex ID %d", e.ID)
+}
+
+// DuplicateVertexError reports a new vertex with an ID that the indexed graph already has.
+type DuplicateVertexError struct {
+	ID int
+}
+
+func (e *DuplicateVertexError) Error() string {
+	return fmt.Sprintf("vertex ID %d already exists", e.ID)
+}
+
+// UnknownEdgeError reports an edge between vertex IDs that the indexed graph does not have.
+type UnknownEdgeError struct {
+	Source int
+	Target int
+}
+
+func (e *UnknownEdgeError) Error() string {
+	return fmt.Sprintf("unknown edge %d -> %d", e.Source, e.Target)
+}
@@ -1,13 +1,38 @@
-package main
+package fruit
 
 import (
+	"errors"
+	"io/fs"
 	"math/rand"
+	"slices"
 	"testing"
 )
 
+// Reads the graph file or skips the test if the file is not part of the checkout.
+func readTestGraph(file string, t *testing.T) *Graph {
+	t.Helper()
+	g, err := ReadGraph(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("Graph file not available: ", file)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func createTestIndex(g *Graph, t *testing.T) *Index {
+	t.Helper()
+	idx, err := CreateIndex(g, H3Concat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
 func testIndexingSchemeFully(file string, t *testing.T) bool {
 	t.Log("Reading graph...\n")
-	g := ReadGraph(file)
+	g := readTestGraph(file, t)
 	t.Log("Read graph with ", g.n, " nodes and ", g.m, " edges.")
 
 	t.Log("Creating matrix using DFS for all vertex-pairs.")
@@ -15,8 +40,8 @@ func testIndexingSchemeFully(file string, t *testing.T) bool {
 	t.Log("Created matrix successfully!")
 
 	t.Log("Creating matrix using indexing scheme.\n")
-	g, _, decomp, scheme := g.RunIndexingScheme()
-	m2 := schemeToMatrix(scheme, decomp, g)
+	dag, _, decomp, scheme := g.RunIndexingScheme(H3Concat)
+	m2 := schemeToMatrix(scheme, decomp, dag)
 	t.Log("Created matrix successfully!")
 
 	areEqual := compQuadraticMatrices(m1, m2)
@@ -31,10 +56,10 @@ func testIndexingSchemeFully(file string, t *testing.T) bool {
 
 func testIndexingSchemeRandomly(file string, nTests int, t *testing.T) bool {
 	t.Log("Reading graph...")
-	originalG := ReadGraph(file) // for DFS calculations
-	g := ReadGraph(file)         // for scheme calculations
+	originalG := readTestGraph(file, t) // for DFS calculations
+	g := readTestGraph(file, t)         // for scheme calculations
 
-	g, _, decomp, scheme := g.RunIndexingScheme()
+	dag, _, decomp, scheme := g.RunIndexingScheme(H3Concat)
 
 	t.Log("Testing scheme...")
 	visited := make([]bool, originalG.n)
@@ -45,7 +70,7 @@ func testIndexingSchemeRandomly(file string, nTests int, t *testing.T) bool {
 		v := rand.Intn(originalG.n)
 		w := rand.Intn(originalG.n)
 
-		schemeAnswer := isReachable(v, w, scheme, decomp, g)
+		schemeAnswer := isReachable(v, w, scheme, decomp, dag)
 		dfsAnswer := originalG.runDFS(v, w, visited, stack, head) != nil
 
 		if schemeAnswer != dfsAnswer {
@@ -65,7 +90,7 @@ func testIndexingSchemeRandomly(file string, nTests int, t *testing.T) bool {
 	return true
 }
 
-func testDecomposition(g *Graph, decomp *Decomposition) bool {
+func testDecomposition(g *CSRGraph, decomp *Decomposition) bool {
 	checked := make([]bool, g.n)
 
 	for cNode := decomp.chains.first; cNode != nil; cNode = cNode.next {
@@ -101,8 +126,7 @@ func TestDecomposition(t *testing.T) {
 	for _, file := range files {
 		t.Log("Testing ", file, "...")
 		t.Run(file, func(t *testing.T) {
-			g := ReadGraph(file)
-			g = g.CollapseToDAG()
+			g := readTestGraph(file, t).CollapseToDAG()
 			topo := g.TopoSort()
 			h3Decomp := g.HthreeConcat(topo)
 			nodeDecomp := g.NodeOrderPathDecomp(topo)
@@ -170,3 +194,147 @@ func TestIndexingSchemeFully(t *testing.T) {
 		})
 	}
 }
+
+func TestIndex(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			m := g.dfsCreateMatrix()
+			idx := createTestIndex(g, t)
+
+			for s, sID := range g.idMapping.vToId {
+				for w, wID := range g.idMapping.vToId {
+					if reachable, err := idx.Reachable(sID, wID); err != nil || reachable != m[s][w] {
+						t.Fatalf("Reachable(%d, %d) = %t, %v, want %t", sID, wID, reachable, err, m[s][w])
+					}
+				}
+			}
+			var unknownErr *UnknownVertexError
+			if _, err := idx.Reachable(-1, 0); !errors.As(err, &unknownErr) || unknownErr.ID != -1 {
+				t.Errorf("Reachable with unknown ID returned %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildStats(t *testing.T) {
+	file := "./data/gnm/gnm_1000_10000.gr"
+	g := readTestGraph(file, t)
+	first := createTestIndex(g, t).Stats()
+
+	if first.Nodes != g.n || first.Edges != g.m || first.SchemeNodes == 0 || first.CollapseEdges == 0 {
+		t.Fatalf("Unexpected statistics: %+v", first)
+	}
+	// Counters of concurrent builds must not influence each other
+	results := make(chan BuildStats, 2)
+	for i := 0; i < 2; i++ {
+		h := readTestGraph(file, t)
+		go func() {
+			idx, err := CreateIndex(h, H3Concat)
+			if err != nil {
+				t.Error(err)
+				results <- first
+				return
+			}
+			results <- idx.Stats()
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		s := <-results
+		if s.RemovedEdges != first.RemovedEdges || s.CollapseNodes != first.CollapseNodes ||
+			s.DecompEdges != first.DecompEdges || s.SchemeEdges != first.SchemeEdges {
+			t.Errorf("Statistics differ between builds: %+v and %+v", s, first)
+		}
+	}
+}
+
+// Checks that the forward-backward algorithm finds the components of Tarjan's algorithm.
+func TestFindSCCsParallel(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_1000.gr",
+		"./data/gnm/gnm_1000_1000.gr",
+		"./data/gnm/gnm_1000_10000.gr",
+		"./data/real_world/Wiki-Vote.gr",
+		"./data/real_world/p2p-Gnutella04_2002.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t).csr()
+			tarjanVToComp, tarjanComps := g.FindSCCs()
+			for _, workers := range []int{1, 4} {
+				vToComp, comps := g.findSCCsParallel(&BuildStats{}, workers)
+				if len(comps) != len(tarjanComps) {
+					t.Fatalf("%d workers found %d components, want %d", workers, len(comps), len(tarjanComps))
+				}
+				for c, comp := range comps {
+					if c > 0 && comp[0] < comps[c-1][0] {
+						t.Fatalf("%d workers: components are not numbered by their smallest vertex", workers)
+					}
+					tarjanComp := tarjanVToComp[comp[0]]
+					if len(comp) != len(tarjanComps[tarjanComp]) {
+						t.Fatalf("%d workers: component of vertex %d has %d vertices, want %d", workers, comp[0], len(comp), len(tarjanComps[tarjanComp]))
+					}
+					for _, v := range comp {
+						if vToComp[v] != c || tarjanVToComp[v] != tarjanComp {
+							t.Fatalf("%d workers: vertex %d is in the wrong component", workers, v)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// Checks that condensing on several goroutines creates the same graph
+// and that indices built with both parallel phases answer correctly.
+func TestCondenseParallel(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_100_1000.gr",
+		"./data/gn/gn_100.gr",
+		"./data/real_world/Wiki-Vote.gr",
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+			g := readTestGraph(file, t)
+			vToComp, comps := g.FindSCCs()
+			dag := g.csr().condense(vToComp, comps, &BuildStats{})
+			parallel := g.csr().condenseParallel(vToComp, comps, &BuildStats{}, 4)
+			if parallel.n != dag.n || parallel.m != dag.m {
+				t.Fatalf("Parallel condensation has %d vertices and %d edges, want %d and %d", parallel.n, parallel.m, dag.n, dag.m)
+			}
+			for v := 0; v < dag.n; v++ {
+				if !slices.Equal(dag.outs(v), parallel.outs(v)) || !slices.Equal(dag.ins(v), parallel.ins(v)) {
+					t.Fatalf("Edges of component %d differ between sequential and parallel condensation", v)
+				}
+			}
+			if g.n > 1000 {
+				return
+			}
+
+			g.SetBuildOptions(BuildOptions{Workers: 4, SCC: ForwardBackward})
+			idx := createTestIndex(g, t)
+			m := g.dfsCreateMatrix()
+			for s, sID := range g.idMapping.vToId {
+				for w, wID := range g.idMapping.vToId {
+					if reachable, err := idx.Reachable(sID, wID); err != nil || reachable != m[s][w] {
+						t.Fatalf("Reachable(%d, %d) = %t, %v, want %t", sID, wID, reachable, err, m[s][w])
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package fruit
 
 import (
 	"math"
@@ -39,12 +39,15 @@ type IdMapping struct {
 	idToV map[int]int
 }
 
+// Graph stores every edge in the adjacency lists of both its endpoints,
+// so edges can be added and removed in O(1). The index is built from
+// its compact form, see CSRGraph.
 type Graph struct {
 	n         int
 	m         int
 	nodes     []Node
-	vToComp   []int
 	idMapping IdMapping
+	options   BuildOptions
 }
 
 type SccData struct {
@@ -56,6 +59,7 @@ type SccData struct {
 	time    []int
 	compToV [][]int
 	onStack []bool
+	stats   *BuildStats
 }
 
 type Stacks struct {
@@ -65,13 +69,36 @@ type Stacks struct {
 }
 
 type NodeCollitions struct {
-	reached []*Edge
+	reached []int // position of the edge reaching each chain, -1 if none
 	changed []int
 }
 
 func CreateGraph(n int) *Graph {
 	nodes := make([]Node, n)
-	return &Graph{n, 0, nodes, nil, IdMapping{}}
+	return &Graph{n, 0, nodes, IdMapping{}, BuildOptions{}}
+}
+
+// Sets the options of the indexes built from g.
+func (g *Graph) SetBuildOptions(options BuildOptions) {
+	g.options = options
+}
+
+// Returns the number of vertices of g.
+func (g *Graph) N() int {
+	return g.n
+}
+
+// Returns the number of edges of g.
+func (g *Graph) M() int {
+	return g.m
+}
+
+// Adds a vertex with the given ID without edges and returns it.
+func (g *Graph) addVertex(id int) int {
+	g.nodes = append(g.nodes, Node{})
+	g.n++
+	g.AddVtoIdMapping(id, g.n-1)
+	return g.n - 1
 }
 
 func (g *Graph) PrintGraph() {
@@ -84,10 +111,6 @@ func (g *Graph) PrintGraph() {
 		}
 		logger.Println()
 	}
-	if g.vToComp != nil {
-		logger.Println("=== V -> Comp:")
-		logger.Println(g.vToComp)
-	}
 	if g.idMapping.vToId != nil {
 		logger.Println("=== ID -> V:")
 		logger.Println(g.idMapping.idToV)
@@ -154,35 +177,41 @@ func unlink(g *Graph, e *Edge, isOut bool) {
 }
 
 func (g *Graph) AddVtoIdMapping(id int, nextIndex int) int {
-	_, contained := g.idMapping.idToV[id]
+	return g.idMapping.add(id, nextIndex)
+}
+
+// Maps id to nextIndex unless it is mapped already.
+// Returns the index for the next new ID.
+func (m IdMapping) add(id int, nextIndex int) int {
+	_, contained := m.idToV[id]
 	if contained {
 		return nextIndex
 	} else {
-		g.idMapping.idToV[id] = nextIndex
-		g.idMapping.vToId[nextIndex] = id
+		m.idToV[id] = nextIndex
+		m.vToId[nextIndex] = id
 	}
 	nextIndex++
 	return nextIndex
 }
 
 // Runs a modified DFS starting from s for topological sorting.
-func topoDFS(s, i int, g *Graph, visited []bool, topoOrder []int, stack, head *Stack[int]) int {
+func topoDFS(s, i int, g *CSRGraph, visited []bool, topoOrder []int, stack, head *Stack[int]) int {
 	stack.Push(s)
 
 	for !stack.IsEmpty() {
-		v := stack.Peek()
+		v, _ := stack.Peek()
 
 		if !visited[v] {
 			visited[v] = true
 			head.Push(v)
 
 			// Consider all outgoing edges
-			for e := g.nodes[v].out; e != nil; e = e.next {
-				if !visited[e.target] {
-					stack.Push(e.target)
+			for _, w := range g.outs(v) {
+				if !visited[w] {
+					stack.Push(int(w))
 				}
 			}
-		} else if head.Peek() == v {
+		} else if top, err := head.Peek(); err == nil && top == v {
 			// Backtracking: Next node detected
 			topoOrder[i] = v
 			i--
@@ -196,7 +225,7 @@ func topoDFS(s, i int, g *Graph, visited []bool, topoOrder []int, stack, head *S
 }
 
 // Topologically sorts the given DAG in O(|V| + |E|).
-func (g *Graph) TopoSort() []int {
+func (g *CSRGraph) TopoSort() []int {
 	visited := make([]bool, g.n)
 	topoOrder := make([]int, g.n)
 	stack := CreateStack[int](g.n)
@@ -205,7 +234,7 @@ func (g *Graph) TopoSort() []int {
 
 	// Create topologgerical order
 	for v := 0; v < g.n; v++ {
-		if g.nodes[v].inDeg == 0 && !visited[v] {
+		if g.inDeg(v) == 0 && !visited[v] {
 			i = topoDFS(v, i, g, visited, topoOrder, stack, head)
 			// Clean up stacks
 			head.ClearStack()
@@ -218,11 +247,11 @@ func (g *Graph) TopoSort() []int {
 // Runs DFS version for strongly connected components on a part of the Graph g.
 // Returns the current time value and the calculated array
 // that maps components to vertices: compToV.
-func sccDFS(data SccData, g *Graph, stacks Stacks) (int, [][]int) {
+func sccDFS(data SccData, g *CSRGraph, stacks Stacks) (int, [][]int) {
 	stacks.recStack.Push(data.u)
-	collapseNodesProcessed++
+	data.stats.CollapseNodes++
 	for !stacks.recStack.IsEmpty() {
-		v := stacks.recStack.Peek()
+		v, _ := stacks.recStack.Peek()
 
 		if data.time[v] == math.MaxInt {
 			// First discovery of this vertex
@@ -231,34 +260,34 @@ func sccDFS(data SccData, g *Graph, stacks Stacks) (int, [][]int) {
 			data.onStack[v] = true
 			stacks.stack.Push(v)
 			stacks.head.Push(v)
-			collapseNodesProcessed++
+			data.stats.CollapseNodes++
 			data.t++
 			// Consider all outgoing edges
-			for e := g.nodes[v].out; e != nil; e = e.next {
-				collapseEdgesProcessed++
-
-				if data.time[e.target] == math.MaxInt {
-					stacks.recStack.Push(e.target)
-					data.pre[e.target] = v
-				} else if data.onStack[e.target] {
-					data.lowLink[v] = min(data.lowLink[v], data.time[e.target])
+			for _, w := range g.outs(v) {
+				data.stats.CollapseEdges++
+
+				if data.time[w] == math.MaxInt {
+					stacks.recStack.Push(int(w))
+					data.pre[w] = v
+				} else if data.onStack[w] {
+					data.lowLink[v] = min(data.lowLink[v], data.time[w])
 				}
 			}
-		} else if stacks.head.Peek() == v {
+		} else if top, err := stacks.head.Peek(); err == nil && top == v {
 			// Backtracking
-			for e := g.nodes[v].out; e != nil; e = e.next {
-				collapseEdgesProcessed++
+			for _, w := range g.outs(v) {
+				data.stats.CollapseEdges++
 
-				if data.pre[e.target] == v {
+				if data.pre[w] == v {
 					// "recursively-called" on this vertex
-					data.lowLink[v] = min(data.lowLink[v], data.lowLink[e.target])
+					data.lowLink[v] = min(data.lowLink[v], data.lowLink[w])
 				}
 			}
 			if data.lowLink[v] == data.time[v] {
 				comp := make([]int, 0, g.n)
 				for {
-					w := stacks.stack.Pop()
-					collapseNodesProcessed++
+					w, _ := stacks.stack.Pop()
+					data.stats.CollapseNodes++
 					data.onStack[w] = false
 					data.vToComp[w] = len(data.compToV)
 					comp = append(comp, w)
@@ -270,10 +299,10 @@ func sccDFS(data SccData, g *Graph, stacks Stacks) (int, [][]int) {
 			}
 			stacks.head.Pop()
 			stacks.recStack.Pop()
-			collapseNodesProcessed++
+			data.stats.CollapseNodes++
 		} else {
 			stacks.recStack.Pop()
-			collapseNodesProcessed++
+			data.stats.CollapseNodes++
 		}
 	}
 	return data.t, data.compToV
@@ -283,6 +312,16 @@ func sccDFS(data SccData, g *Graph, stacks Stacks) (int, [][]int) {
 // Returns an array that maps the vertices to components (vToComp)
 // and another array that maps the components to vertices.
 func (g *Graph) FindSCCs() ([]int, [][]int) {
+	return g.csr().FindSCCs()
+}
+
+// Tarjan's strongly connected components algorithm, see Graph.FindSCCs.
+func (g *CSRGraph) FindSCCs() ([]int, [][]int) {
+	return g.findSCCs(&BuildStats{})
+}
+
+// Tarjan's strongly connected components algorithm counting its work in stats.
+func (g *CSRGraph) findSCCs(stats *BuildStats) ([]int, [][]int) {
 	lowLink := make([]int, g.n)
 	time := make([]int, g.n)
 	vToComp := make([]int, g.n)
@@ -296,17 +335,17 @@ func (g *Graph) FindSCCs() ([]int, [][]int) {
 	t := 0
 
 	for i := 0; i < g.n; i++ {
-		collapseNodesProcessed++
+		stats.CollapseNodes++
 		lowLink[i] = math.MaxInt
 		time[i] = math.MaxInt
 	}
 	// Consider all nodes
 	for v := 0; v < g.n; v++ {
-		collapseNodesProcessed++
+		stats.CollapseNodes++
 
 		if time[v] == math.MaxInt {
 			data := SccData{
-				v, t, lowLink, pre, vToComp, time, compToV, onStack,
+				v, t, lowLink, pre, vToComp, time, compToV, onStack, stats,
 			}
 			t, compToV = sccDFS(data, g, stacks)
 			// Clean up stacks
@@ -318,14 +357,36 @@ func (g *Graph) FindSCCs() ([]int, [][]int) {
 	return vToComp, compToV
 }
 
+// Collapses the compact form of g to its strongly connected components.
+func (g *Graph) CollapseToDAG() *CSRGraph {
+	return g.csr().CollapseToDAG()
+}
+
 // Collapses the given graph to its strongly connected components.
-// Uses Tarjan's Strongly-connected-components algorithm.
-// Runs in O(|V|+|E|).
-func (g *Graph) CollapseToDAG() *Graph {
-	vToComp, compToV := g.FindSCCs()
-	gPrime := CreateGraph(len(compToV))
-	gPrime.vToComp = vToComp
-	gPrime.idMapping = g.idMapping
+// Uses the algorithm selected by the build options of g, Tarjan's by default.
+// The returned DAG carries fresh build statistics holding the collapse counters.
+// Runs in O(|V|+|E|) with Tarjan's algorithm.
+func (g *CSRGraph) CollapseToDAG() *CSRGraph {
+	stats := &BuildStats{Nodes: g.n, Edges: g.m}
+	var vToComp []int
+	var compToV [][]int
+	if g.options.SCC == ForwardBackward {
+		vToComp, compToV = g.findSCCsParallel(stats, g.options.Workers)
+	} else {
+		vToComp, compToV = g.findSCCs(stats)
+	}
+	gPrime := g.condense(vToComp, compToV, stats)
+	stats.SCCs = gPrime.n
+	return gPrime
+}
+
+// Creates the graph of the given components of g with an edge between two
+// components if g has an edge between their vertices in O(|V| + |E|).
+func (g *CSRGraph) condense(vToComp []int, compToV [][]int, stats *BuildStats) *CSRGraph {
+	if g.options.Workers > 1 {
+		return g.condenseParallel(vToComp, compToV, stats, g.options.Workers)
+	}
+	var sources, targets []int32
 	collision := make([]bool, len(compToV))
 	changed := make([]int, len(compToV)) // for resetting changed values for later nodes
 
@@ -333,16 +394,16 @@ func (g *Graph) CollapseToDAG() *Graph {
 		i := 0
 		comp := compToV[compNr]
 		for _, v := range comp {
-			collapseNodesProcessed++
-			for e := g.nodes[v].out; e != nil; e = e.next {
-				collapseEdgesProcessed++
+			stats.CollapseNodes++
+			for _, w := range g.outs(v) {
+				stats.CollapseEdges++
 
-				tCompNr := vToComp[e.target]
+				tCompNr := vToComp[w]
 				if compNr != tCompNr && !collision[tCompNr] {
 					changed[i] = tCompNr
 					i++
-					ePrime := Edge{compNr, tCompNr, nil, nil, nil}
-					gPrime.AddEdge(&ePrime)
+					sources = append(sources, int32(compNr))
+					targets = append(targets, int32(tCompNr))
 					collision[tCompNr] = true
 				}
 			}
@@ -353,80 +414,96 @@ func (g *Graph) CollapseToDAG() *Graph {
 			i--
 		}
 	}
+	gPrime := createCSRGraphFromEdges(len(compToV), sources, targets)
+	gPrime.stats = stats
+	gPrime.vToComp = vToComp
+	gPrime.idMapping = g.idMapping
+	gPrime.options = g.options
 	return gPrime
 }
 
 // Removes incoming or outgoing edges for chains, depending on isVtoC flag in O(|V|+|E|).
-func (g *Graph) removeOneSidedTransitiveEdges(collitions NodeCollitions, decomp *Decomposition, isVtoC bool) {
+// Marks the removed edges by their position in the outgoing rows.
+func (g *CSRGraph) removeOneSidedTransitiveEdges(collitions NodeCollitions, removed []bool, decomp *Decomposition, isVtoC bool) {
+	start, ends, edge := g.inStart, g.in, g.inEdge // Use incoming rows (for chain to vertex removal)
+	if isVtoC {
+		start, ends, edge = g.outStart, g.out, nil // Use outgoing rows (for vertex to chain removal)
+	}
+	// Returns the position in the outgoing rows of the edge at position p of the used rows
+	edgeAt := func(p int) int {
+		if edge == nil {
+			return p
+		}
+		return edge[p]
+	}
 	for v := 0; v < g.n; v++ {
 		i := 0
-		e := g.nodes[v].in // Use incoming edge-list (for vertex to chain removal)
-		if isVtoC {
-			e = g.nodes[v].out // Use outgoing edge-list (for vertex to chain removal)
-		}
-		for e != nil {
-			nextE := e.next
-			w := e.source // Use chain of source node of edge (for vertex to chain removal)
-			if isVtoC {
-				w = e.target // Use chain of target node of edge (for chain to vertex removal)
+		for p := start[v]; p < start[v+1]; p++ {
+			if removed[edgeAt(p)] {
+				continue
 			}
+			// Use chain of the other end of the edge, the target for vertex to chain
+			// removal and the source for chain to vertex removal
+			w := ends[p]
 			wChain := decomp.vToChain[w].chain.val
-			if collitions.reached[wChain.id] == nil {
-				collitions.reached[wChain.id] = e
+			if collitions.reached[wChain.id] == -1 {
+				collitions.reached[wChain.id] = p
 				collitions.changed[i] = wChain.id
 				i++
 			} else {
 				// Handle already reached chain of current target
-				oldEdge := collitions.reached[wChain.id]
-				numRemovedTransitveEdges++
-
-				newPosT := decomp.vToChain[e.target].pos
-				oldPosT := decomp.vToChain[oldEdge.target].pos
-				newPosS := decomp.vToChain[e.source].pos
-				oldPosS := decomp.vToChain[oldEdge.source].pos
-				if (newPosT > oldPosT && isVtoC) || (newPosS < oldPosS && !isVtoC) {
-					unlink(g, e, isVtoC)
+				old := collitions.reached[wChain.id]
+				g.stats.RemovedEdges++
+				g.m--
+
+				newPos := decomp.vToChain[w].pos
+				oldPos := decomp.vToChain[ends[old]].pos
+				if (newPos > oldPos && isVtoC) || (newPos < oldPos && !isVtoC) {
+					removed[edgeAt(p)] = true
 				} else {
-					unlink(g, oldEdge, isVtoC)
-					collitions.reached[wChain.id] = e
+					removed[edgeAt(old)] = true
+					collitions.reached[wChain.id] = p
 				}
 			}
-			e = nextE
 		}
 		// Clear reached-indices for the next vertex
 		for i > 0 {
-			collitions.reached[collitions.changed[i-1]] = nil
+			collitions.reached[collitions.changed[i-1]] = -1
 			i--
 		}
 	}
 }
 
 // Heuristic to remove transitive edges in O(|V| + |E|).
-func (g *Graph) RemoveTransitiveEdges(decomp *Decomposition) {
-	reached := make([]*Edge, decomp.chains.n)
+// Removed edges are skipped by the second pass and dropped at the end.
+func (g *CSRGraph) RemoveTransitiveEdges(decomp *Decomposition) {
+	reached := make([]int, decomp.chains.n)
+	for c := range reached {
+		reached[c] = -1
+	}
 	changed := make([]int, decomp.chains.n) // for resetting changed values for later nodes
 	collitions := NodeCollitions{reached, changed}
+	removed := make([]bool, len(g.out))
 	// Remove transitive edges from vertex to chain
-	g.removeOneSidedTransitiveEdges(collitions, decomp, true)
+	g.removeOneSidedTransitiveEdges(collitions, removed, decomp, true)
 	// Remove transitive edges from chain to vertex
-	g.removeOneSidedTransitiveEdges(collitions, decomp, false)
+	g.removeOneSidedTransitiveEdges(collitions, removed, decomp, false)
+	g.compact(removed)
 }
 
 // Topologically sorts the outgoing edges of the given graph in O(|V| + |E|).
-func (g *Graph) TopoSortOutEdges(topoOrder []int) {
-	edgeList := make([]*Edge, g.n)
-	// Fill new adjacency lists in topologgerical order
+func (g *CSRGraph) TopoSortOutEdges(topoOrder []int) {
+	cursor := make([]int, g.n)
+	copy(cursor, g.outStart[1:])
+	// Fill every outgoing row from its end in reversed topological order
 	for i := len(topoOrder) - 1; i >= 0; i-- {
 		v := topoOrder[i]
 
-		for e := g.nodes[v].in; e != nil; e = e.next {
-			e.partner.next = nil
-			e.partner.prev = nil
-			edgeList[e.source] = pushEdgeToList(e.partner, edgeList[e.source])
+		for j := g.inStart[v]; j < g.inStart[v+1]; j++ {
+			s := g.in[j]
+			cursor[s]--
+			g.out[cursor[s]] = int32(v)
+			g.inEdge[j] = cursor[s]
 		}
 	}
-	// Replace old outgoing adjacency lists
-	for v := 0; v < g.n; v++ {
-		g.nodes[v].out = edgeList[v]
-	}
 }
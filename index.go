@@ -0,0 +1,283 @@
+package fruit
+
+import (
+	"sort"
+	"sync"
+)
+
+// Index answers reachability queries on the graph it was built from.
+// Queries only use the flat mappings and the scheme, which is all
+// that is kept when an index is loaded from a file.
+type Index struct {
+	n         int   // vertices of the indexed graph
+	m         int   // edges of the indexed graph
+	vToComp   []int // vertex -> component
+	chainOf   []int // component -> chain id
+	posOf     []int // component -> position in its chain
+	scheme    Scheme
+	reverse   Scheme // scheme of the reversed graph, nil until CreateReverse
+	idMapping IdMapping
+	stats     BuildStats
+
+	// ID tables of a mapped index, used instead of idMapping
+	vToIdTable []int // vertex -> ID or noID
+	idTable    []int // (ID, vertex) pairs sorted by ID
+	unmap      func() error
+
+	// Tables for enumerating vertices, computed on first use
+	tablesOnce sync.Once
+	members    [][]int // component -> vertices
+	chainComps [][]int // chain -> components by position, -1 before chainBase
+	chainSizes [][]int // chain -> vertices in the components before each position
+	chainBase  []int   // chain -> position of its first component
+
+	// Graph the index was built from, nil if it was loaded from a file or built from a CSRGraph
+	src    *Graph
+	method DecompMethod
+}
+
+// Builds the reachability index of g using the given decomposition heuristic.
+// Returns ErrGraphSize if g has more than 2^31-1 vertices.
+func CreateIndex(g *Graph, method DecompMethod) (*Index, error) {
+	if err := checkCSRSize(g.n); err != nil {
+		return nil, err
+	}
+	return createIndex(g.csr(), g, method), nil
+}
+
+// Builds the reachability index of the compact graph g. Like a loaded index,
+// it has no graph for paths, updates and the reverse scheme.
+// Returns ErrGraphSize if g has more than 2^31-1 vertices.
+func CreateIndexFromCSR(g *CSRGraph, method DecompMethod) (*Index, error) {
+	if err := checkCSRSize(g.n); err != nil {
+		return nil, err
+	}
+	return createIndex(g, nil, method), nil
+}
+
+// Builds the index of the compact form g of src, which may be nil.
+func createIndex(g *CSRGraph, src *Graph, method DecompMethod) *Index {
+	dag, _, decomp, scheme := g.RunIndexingScheme(method)
+	idx := &Index{
+		n:         g.n,
+		m:         g.m,
+		vToComp:   dag.vToComp,
+		chainOf:   make([]int, dag.n),
+		posOf:     make([]int, dag.n),
+		scheme:    scheme,
+		idMapping: g.idMapping,
+		stats:     *dag.stats,
+		src:       src,
+		method:    method,
+	}
+	for c, cm := range decomp.vToChain {
+		idx.chainOf[c] = cm.chain.val.id
+		idx.posOf[c] = cm.pos
+	}
+	return idx
+}
+
+// Returns the statistics collected while building the index.
+func (idx *Index) Stats() BuildStats {
+	return idx.stats
+}
+
+// Returns the number of vertices of the indexed graph.
+func (idx *Index) N() int {
+	return idx.n
+}
+
+// Returns the number of edges of the indexed graph.
+func (idx *Index) M() int {
+	return idx.m
+}
+
+// Returns whether the vertex with ID s can reach the vertex with ID t in O(1).
+// Returns an UnknownVertexError for IDs that do not occur in the graph.
+func (idx *Index) Reachable(s, t int) (bool, error) {
+	v, err := idx.vertex(s)
+	if err != nil {
+		return false, err
+	}
+	w, err := idx.vertex(t)
+	if err != nil {
+		return false, err
+	}
+	return idx.reachable(v, w), nil
+}
+
+// Returns whether vertex s can reach vertex t.
+func (idx *Index) reachable(s, t int) bool {
+	return idx.compReaches(idx.vToComp[s], idx.vToComp[t])
+}
+
+// Returns whether component s can reach component t.
+func (idx *Index) compReaches(s, t int) bool {
+	if s == t {
+		return true
+	}
+	tChain := idx.chainOf[t]
+	return idx.scheme.Get(s, tChain) < idx.scheme.Get(t, tChain)
+}
+
+// Computes the tables once. Updates of the index keep them up to date.
+func (idx *Index) buildTables() {
+	idx.tablesOnce.Do(idx.computeTables)
+}
+
+// Computes the vertices of every component and the components of every chain.
+func (idx *Index) computeTables() {
+	idx.members = make([][]int, len(idx.chainOf))
+	for v, c := range idx.vToComp {
+		idx.members[c] = append(idx.members[c], v)
+	}
+	lengths := make([]int, idx.scheme.Chains())
+	for c := range idx.chainOf {
+		lengths[idx.chainOf[c]]++
+	}
+	idx.chainComps = make([][]int, len(lengths))
+	for chain, length := range lengths {
+		idx.chainComps[chain] = make([]int, length)
+	}
+	for c := range idx.chainOf {
+		idx.chainComps[idx.chainOf[c]][idx.posOf[c]] = c
+	}
+	idx.chainBase = make([]int, len(lengths))
+	idx.computeChainSizes()
+}
+
+// Computes the number of vertices in the components before each position of
+// every chain. Assumes that the chains start at position 0.
+func (idx *Index) computeChainSizes() {
+	idx.chainSizes = make([][]int, len(idx.chainComps))
+	for chain, comps := range idx.chainComps {
+		idx.chainSizes[chain] = make([]int, len(comps)+1)
+		for i, c := range comps {
+			idx.chainSizes[chain][i+1] = idx.chainSizes[chain][i] + len(idx.members[c])
+		}
+	}
+}
+
+// Returns the decomposition given by the chain tables.
+func (idx *Index) decomposition() *Decomposition {
+	idx.buildTables()
+	decomp := &Decomposition{make([]ChainMapping, len(idx.chainOf)), createLinkedList[Chain]()}
+	for chain, comps := range idx.chainComps {
+		cNode := createListNode(createChain(chain))
+		for pos, c := range comps[idx.chainBase[chain]:] {
+			getEntries(cNode).Add(createListNode(c))
+			decomp.vToChain[c] = ChainMapping{cNode, pos}
+		}
+		decomp.chains.Add(cNode)
+	}
+	return decomp
+}
+
+// Calls f for the component of the target of every edge leaving component c.
+func (idx *Index) eachOutComp(c int, f func(d int)) {
+	for _, v := range idx.compMembers()[c] {
+		for e := idx.src.nodes[v].out; e != nil; e = e.next {
+			if d := idx.vToComp[e.target]; d != c {
+				f(d)
+			}
+		}
+	}
+}
+
+// Calls f for the component of the source of every edge entering component c.
+func (idx *Index) eachInComp(c int, f func(d int)) {
+	for _, v := range idx.compMembers()[c] {
+		for e := idx.src.nodes[v].in; e != nil; e = e.next {
+			if d := idx.vToComp[e.source]; d != c {
+				f(d)
+			}
+		}
+	}
+}
+
+// Returns the vertices of every component.
+func (idx *Index) compMembers() [][]int {
+	idx.buildTables()
+	return idx.members
+}
+
+// Releases the memory mapping of an index opened with OpenMapped.
+// The index must not be used afterwards. Does nothing for other indices.
+func (idx *Index) Close() error {
+	if idx.unmap == nil {
+		return nil
+	}
+	unmap := idx.unmap
+	idx.unmap = nil
+	return unmap()
+}
+
+// Returns the ID of vertex v in the input file.
+func (idx *Index) ID(v int) (int, bool) {
+	if idx.vToIdTable != nil {
+		id := idx.vToIdTable[v]
+		return id, int64(id) != noID
+	}
+	id, ok := idx.idMapping.vToId[v]
+	return id, ok
+}
+
+// Returns the vertex with the given ID or an UnknownVertexError.
+func (idx *Index) vertex(id int) (int, error) {
+	v, ok := idx.Vertex(id)
+	if !ok {
+		return -1, &UnknownVertexError{id}
+	}
+	return v, nil
+}
+
+// Returns the vertices with the given IDs or an UnknownVertexError.
+func (idx *Index) vertices(ids []int) ([]int, error) {
+	vertices := make([]int, len(ids))
+	for i, id := range ids {
+		v, err := idx.vertex(id)
+		if err != nil {
+			return nil, err
+		}
+		vertices[i] = v
+	}
+	return vertices, nil
+}
+
+// Returns f called with the ID of a vertex instead of the vertex.
+// Only vertices without edges have no ID, they are never passed to f.
+func (idx *Index) withIDs(f func(id int) bool) func(v int) bool {
+	return func(v int) bool {
+		id, _ := idx.ID(v)
+		return f(id)
+	}
+}
+
+// Returns the vertex with the given ID in the input file.
+func (idx *Index) Vertex(id int) (int, bool) {
+	if idx.idTable != nil {
+		pairs := len(idx.idTable) / 2
+		i := sort.Search(pairs, func(i int) bool {
+			return idx.idTable[2*i] >= id
+		})
+		if i < pairs && idx.idTable[2*i] == id {
+			return idx.idTable[2*i+1], true
+		}
+		return -1, false
+	}
+	v, ok := idx.idMapping.idToV[id]
+	return v, ok
+}
+
+// Returns the transitive closure matrix of the indexed graph in O(|V|^2).
+// Rows and columns are vertices, see ID for their IDs.
+func (idx *Index) Matrix() [][]bool {
+	matrix := make([][]bool, idx.n)
+	for v := 0; v < idx.n; v++ {
+		matrix[v] = make([]bool, idx.n)
+		for w := 0; w < idx.n; w++ {
+			matrix[v][w] = idx.reachable(v, w)
+		}
+	}
+	return matrix
+}
@@ -1,48 +1,77 @@
-package main
+package fruit
 
-import (
-	"math"
-)
+// Returns the number of vertices of the longest chain.
+func (decomp *Decomposition) longestChain() int {
+	longest := 0
+	for cNode := decomp.chains.first; cNode != nil; cNode = cNode.next {
+		longest = max(longest, cNode.val.entries.n)
+	}
+	return longest
+}
 
 // Creates the indexing scheme in O(|E_{tr}| + k_c * |E_{red}|).
-func (g *Graph) CreateIndexingScheme(topo []int, decomp *Decomposition) [][]int {
-	indexingScheme := make([][]int, g.n)
-	// Initialize indexing scheme
-	for v := 0; v < g.n; v++ {
-		schemeNodesProcessed++
-		vScheme := make([]int, decomp.chains.n)
-		indexingScheme[v] = vScheme
-		// Set all reachable indices to infinity
-		for i := 0; i < len(vScheme); i++ {
-			vScheme[i] = math.MaxInt
-		}
+// Entries are stored with the smallest width that fits the longest chain.
+// Rows only keep their finite entries as long as this takes at most half
+// the memory of the dense scheme, otherwise the dense scheme is used.
+func (g *CSRGraph) CreateIndexingScheme(topo []int, decomp *Decomposition) Scheme {
+	var indexingScheme Scheme
+	width := schemeWidthFor(decomp.longestChain())
+	switch width {
+	case 1:
+		indexingScheme = createIndexingScheme[uint8](g, topo, decomp, width)
+	case 2:
+		indexingScheme = createIndexingScheme[uint16](g, topo, decomp, width)
+	case 4:
+		indexingScheme = createIndexingScheme[uint32](g, topo, decomp, width)
+	default:
+		indexingScheme = createIndexingScheme[uint64](g, topo, decomp, width)
+	}
+	g.stats.SchemeWidth = width
+	g.stats.SchemeSparse = indexingScheme.Sparse()
+	g.stats.SchemeEntries = indexingScheme.Entries()
+	return indexingScheme
+}
+
+func createIndexingScheme[T schemeEntry](g *CSRGraph, topo []int, decomp *Decomposition, width int) Scheme {
+	if g.options.Workers > 1 {
+		return createIndexingSchemeParallel[T](g, topo, decomp, width, g.options.Workers)
 	}
-	// Fill indexing scheme
-	for i := len(topo) - 1; i >= 0; i-- {
+	g.stats.SchemeNodes += uint(g.n)
+	// Start with sparse rows, which only merge finite entries
+	sparse := createSparseScheme[T](g.n, decomp.chains.n, width)
+	maxBytes := g.n * decomp.chains.n * width / 2
+	i := sparse.fill(g, topo, len(topo)-1, decomp, maxBytes)
+	if i < 0 && sparse.bytes() <= maxBytes {
+		return sparse
+	}
+	// Too many finite entries, continue on a dense scheme
+	indexingScheme := sparse.toDense()
+	for ; i >= 0; i-- {
 		v := topo[i]
-		schemeNodesProcessed++
+		g.stats.SchemeNodes++
+		g.stats.SchemeEdges += uint(g.outDeg(v))
+		fillDenseRow(indexingScheme, v, g, decomp)
+	}
+	return indexingScheme
+}
 
-		for e := g.nodes[v].out; e != nil; e = e.next {
-			schemeEdgesProcessed++
-			// Assuming outgoing edges are already sorted in topologgerical order
-			tChain := decomp.vToChain[e.target].chain.val
-			if indexingScheme[v][tChain.id] >= indexingScheme[e.target][tChain.id] {
-				// Update indices
-				for j := 0; j < decomp.chains.n; j++ {
-					indexingScheme[v][j] = min(indexingScheme[v][j], indexingScheme[e.target][j])
-				}
-				if indexingScheme[v][tChain.id] > decomp.vToChain[e.target].pos {
-					indexingScheme[v][tChain.id] = decomp.vToChain[e.target].pos
-				}
-			}
+// Computes the row of v in a dense scheme from the rows of its successors.
+// Only writes the row of v.
+func fillDenseRow(indexingScheme Scheme, v int, g *CSRGraph, decomp *Decomposition) {
+	for _, t := range g.outs(v) {
+		// Assuming outgoing edges are already sorted in topologgerical order
+		tChain := decomp.vToChain[t].chain.val
+		if indexingScheme.Get(v, tChain.id) >= indexingScheme.Get(int(t), tChain.id) {
+			// Update indices
+			indexingScheme.mergeRow(v, int(t))
+			indexingScheme.lower(v, tChain.id, decomp.vToChain[t].pos)
 		}
 	}
-	return indexingScheme
 }
 
 // Converts a vertex to its respective components to use in the algorithm.
 // Returns v if there are no components.
-func convertV(v int, g *Graph) int {
+func convertV(v int, g *CSRGraph) int {
 	if g.vToComp != nil {
 		v = g.vToComp[v]
 	}
@@ -50,7 +79,7 @@ func convertV(v int, g *Graph) int {
 }
 
 // Queries the reachability indexing scheme whether s can reach t in O(1).
-func isReachable(s, t int, indexingScheme [][]int, decomp *Decomposition, g *Graph) bool {
+func isReachable(s, t int, indexingScheme Scheme, decomp *Decomposition, g *CSRGraph) bool {
 	s = convertV(s, g)
 	t = convertV(t, g)
 
@@ -58,13 +87,13 @@ func isReachable(s, t int, indexingScheme [][]int, decomp *Decomposition, g *Gra
 		return true
 	}
 	tChain := decomp.vToChain[t].chain.val
-	sIndex := indexingScheme[s][tChain.id]
-	tIndex := indexingScheme[t][tChain.id]
+	sIndex := indexingScheme.Get(s, tChain.id)
+	tIndex := indexingScheme.Get(t, tChain.id)
 	return sIndex < tIndex
 }
 
 // Converts the indexing scheme to a reachability matrix in O(|V|^2).
-func schemeToMatrix(indexingScheme [][]int, decomp *Decomposition, g *Graph) [][]bool {
+func schemeToMatrix(indexingScheme Scheme, decomp *Decomposition, g *CSRGraph) [][]bool {
 	// Create n*n matrix
 	matrix := make([][]bool, len(g.vToComp))
 	for i := 0; i < len(g.vToComp); i++ {
@@ -81,16 +110,16 @@ func schemeToMatrix(indexingScheme [][]int, decomp *Decomposition, g *Graph) [][
 	return matrix
 }
 
-func printScheme(indexingScheme [][]int) {
-	for v := 0; v < len(indexingScheme); v++ {
+func printScheme(indexingScheme Scheme) {
+	for v := 0; v < indexingScheme.Rows(); v++ {
 		logger.Println(v, ": [ ")
-		for i := 0; i < len(indexingScheme[v]); i++ {
-			if indexingScheme[v][i] == math.MaxInt {
+		for i := 0; i < indexingScheme.Chains(); i++ {
+			if isInfinite(indexingScheme.Get(v, i)) {
 				logger.Println("inf")
 			} else {
-				logger.Println(indexingScheme[v][i])
+				logger.Println(indexingScheme.Get(v, i))
 			}
-			if i < len(indexingScheme[v])-1 {
+			if i < indexingScheme.Chains()-1 {
 				logger.Println(", ")
 			}
 		}
@@ -1,59 +1,110 @@
-package main
+package fruit
 
 import (
 	"bufio"
 	"fmt"
 	"os"
+	"strings"
 )
 
-func ReadGraph(path string) *Graph {
+// Reads a graph file consisting of a header line "n: <number of vertices>"
+// followed by one "<source> <target>" line per edge.
+// Vertex IDs are remapped to the indices 0..n-1 in order of appearance.
+// Graphs too large to be indexed are rejected with ErrGraphSize.
+func ReadGraph(path string) (*Graph, error) {
+	var g *Graph
+	idMapping, err := scanGraph(path, func(n int) {
+		g = CreateGraph(n)
+	}, func(v, w int) {
+		e := Edge{v, w, nil, nil, nil}
+		g.AddEdge(&e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.idMapping = idMapping
+	return g, nil
+}
+
+// Reads a graph file like ReadGraph straight into the compact form,
+// which takes a fraction of the memory but cannot be updated.
+func ReadCSR(path string) (*CSRGraph, error) {
+	n := 0
+	var sources, targets []int32
+	idMapping, err := scanGraph(path, func(header int) {
+		n = header
+	}, func(v, w int) {
+		sources = append(sources, int32(v))
+		targets = append(targets, int32(w))
+	})
+	if err != nil {
+		return nil, err
+	}
+	g := createCSRGraphFromEdges(n, sources, targets)
+	g.idMapping = idMapping
+	return g, nil
+}
+
+// Parses a graph file, calling header with the number of vertices and then
+// edge with the vertex indices of every edge in the order of the file.
+// Returns the mapping between vertex IDs and indices.
+func scanGraph(path string, header func(n int), edge func(v, w int)) (IdMapping, error) {
 	logger.Println("Reading Graph...")
 
 	file, err := os.Open(path)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error opening file: ", err)
-		os.Exit(1)
+		return IdMapping{}, err
 	}
 
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
-	n, _ := -1, -1
+	n := -1
 
-	if scanner.Scan() {
-		line := scanner.Text()
-
-		fmt.Sscanf(line, "n: %d", &n)
-	} else {
-		fmt.Fprintln(os.Stderr, "Error reading header of file: ", err)
-		os.Exit(1)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return IdMapping{}, fmt.Errorf("reading header of %s: %w", path, err)
+		}
+		return IdMapping{}, &ParseError{path, 1, "", ErrHeader}
 	}
-	g := CreateGraph(n)
-	g.idMapping = IdMapping{make(map[int]int), make(map[int]int)}
+	line := scanner.Text()
+	if _, err := fmt.Sscanf(line, "n: %d", &n); err != nil || n < 0 {
+		return IdMapping{}, &ParseError{path, 1, line, ErrHeader}
+	}
+	// Indexes are built from the compact form, which cannot hold larger graphs
+	if err := checkCSRSize(n); err != nil {
+		return IdMapping{}, &ParseError{path, 1, line, err}
+	}
+	header(n)
+	idMapping := IdMapping{make(map[int]int), make(map[int]int)}
 	nextIndex := 0
 
 	sourceID, targetID := -1, -1
-	for scanner.Scan() {
+	for lineNr := 2; scanner.Scan(); lineNr++ {
 		line := scanner.Text()
-		_, err := fmt.Sscanf(line, "%d %d", &sourceID, &targetID)
-		if err == nil {
-			nextIndex = g.AddVtoIdMapping(sourceID, nextIndex)
-			nextIndex = g.AddVtoIdMapping(targetID, nextIndex)
-			v := g.idMapping.idToV[sourceID]
-			w := g.idMapping.idToV[targetID]
-
-			e := Edge{v, w, nil, nil, nil}
-			g.AddEdge(&e)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if _, err := fmt.Sscanf(line, "%d %d", &sourceID, &targetID); err != nil {
+			return IdMapping{}, &ParseError{path, lineNr, line, err}
+		}
+		for _, id := range []int{sourceID, targetID} {
+			nextIndex = idMapping.add(id, nextIndex)
+			if nextIndex > n {
+				return IdMapping{}, &VertexRangeError{path, lineNr, id, n}
+			}
 		}
+		edge(idMapping.idToV[sourceID], idMapping.idToV[targetID])
 	}
 	if err := scanner.Err(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error reading edges of file: ", err)
-		os.Exit(1)
+		return IdMapping{}, fmt.Errorf("reading edges of %s: %w", path, err)
 	}
-	return g
+	return idMapping, nil
 }
 
-func (g *Graph) WriteGraph(path string, edgeValues []int) {
+// Writes g in the format read by ReadGraph, appending the value of every
+// edge to its line if edgeValues is not nil.
+func (g *Graph) WriteGraph(path string, edgeValues []int) (err error) {
 	file, err := os.OpenFile(
 		path,
 		os.O_CREATE|os.O_WRONLY|os.O_TRUNC,
@@ -65,18 +116,20 @@ func (g *Graph) WriteGraph(path string, edgeValues []int) {
 		// The group and others have read permissions.
 	)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error opening file: ", err)
-		os.Exit(1)
+		return err
 	}
-
-	defer file.Close()
+	// Close the file exactly once, reporting its error only on success
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
 
 	// Write header
 	header := fmt.Sprintf("n: %d\n", g.n)
 	_, err = file.WriteString(header)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error writing header of file: ", err)
-		os.Exit(1)
+		return fmt.Errorf("writing header of %s: %w", path, err)
 	}
 	// Write edges
 	edgeNumber := 0
@@ -89,11 +142,10 @@ func (g *Graph) WriteGraph(path string, edgeValues []int) {
 			edgeStr = fmt.Sprintf("%s\n", edgeStr)
 			_, err = file.WriteString(edgeStr)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "Error writing edges of file: ", err)
-				os.Exit(1)
-
+				return fmt.Errorf("writing edges of %s: %w", path, err)
 			}
 			edgeNumber++
 		}
 	}
+	return nil
 }
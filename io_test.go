@@ -0,0 +1,109 @@
+package fruit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(content string, t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "graph.gr")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadGraphErrors(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		_, err := ReadGraph(writeTestFile("nodes 3\n0 1\n", t))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != 1 || !errors.Is(err, ErrHeader) {
+			t.Fatalf("expected header error, got %v", err)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		_, err := ReadGraph(writeTestFile("", t))
+		if !errors.Is(err, ErrHeader) {
+			t.Fatalf("expected header error, got %v", err)
+		}
+	})
+	t.Run("malformed line", func(t *testing.T) {
+		_, err := ReadGraph(writeTestFile("n: 3\n0 1\n1 x\n", t))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != 3 {
+			t.Fatalf("expected parse error in line 3, got %v", err)
+		}
+	})
+	t.Run("vertex out of range", func(t *testing.T) {
+		_, err := ReadGraph(writeTestFile("n: 2\n0 1\n1 2\n", t))
+		var rangeErr *VertexRangeError
+		if !errors.As(err, &rangeErr) || rangeErr.Line != 3 || rangeErr.ID != 2 {
+			t.Fatalf("expected range error for vertex 2 in line 3, got %v", err)
+		}
+	})
+	t.Run("too many vertices", func(t *testing.T) {
+		path := writeTestFile("n: 2147483648\n0 1\n", t)
+		for _, read := range []func(string) error{
+			func(path string) error { _, err := ReadGraph(path); return err },
+			func(path string) error { _, err := ReadCSR(path); return err },
+		} {
+			var parseErr *ParseError
+			if err := read(path); !errors.As(err, &parseErr) || parseErr.Line != 1 || !errors.Is(err, ErrGraphSize) {
+				t.Fatalf("expected size error in line 1, got %v", err)
+			}
+		}
+		if _, err := CreateIndex(&Graph{n: 1 << 31}, H3Concat); !errors.Is(err, ErrGraphSize) {
+			t.Fatalf("expected size error for 2^31 vertices, got %v", err)
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		_, err := ReadGraph(filepath.Join(t.TempDir(), "missing.gr"))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected not-exist error, got %v", err)
+		}
+	})
+}
+
+func TestWriteGraph(t *testing.T) {
+	g, err := ReadGraph(writeTestFile("n: 3\n0 1\n\n1 2\n", t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "out.gr")
+	if err := g.WriteGraph(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	h, err := ReadGraph(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.n != g.n || h.m != g.m {
+		t.Fatalf("read back (%d, %d), want (%d, %d)", h.n, h.m, g.n, g.m)
+	}
+	if err := g.WriteGraph(filepath.Join(t.TempDir(), "missing", "out.gr"), nil); err == nil {
+		t.Fatal("expected error writing into missing directory")
+	}
+}
+
+func TestStackAndListErrors(t *testing.T) {
+	s := CreateStack[int](1)
+	if _, err := s.Pop(); !errors.Is(err, ErrEmptyStack) {
+		t.Errorf("Pop on empty stack: got %v", err)
+	}
+	if _, err := s.Peek(); !errors.Is(err, ErrEmptyStack) {
+		t.Errorf("Peek on empty stack: got %v", err)
+	}
+	l := createLinkedList[int]()
+	if err := l.Add(nil); !errors.Is(err, ErrNilNode) {
+		t.Errorf("Add nil node: got %v", err)
+	}
+	if err := l.Unlink(createListNode(1)); !errors.Is(err, ErrEmptyList) {
+		t.Errorf("Unlink from empty list: got %v", err)
+	}
+	if _, err := collectGraphFiles(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error collecting files of missing directory")
+	}
+}
@@ -1,8 +1,7 @@
-package main
+package fruit
 
 import (
 	"fmt"
-	"os"
 )
 
 type ListNode[T any] struct {
@@ -26,33 +25,35 @@ func createListNode[T any](v T) *ListNode[T] {
 	return &n
 }
 
-func (l *LinkedList[T]) Add(node *ListNode[T]) {
+// Appends node to the end of l.
+// Returns ErrNilNode if node is nil.
+func (l *LinkedList[T]) Add(node *ListNode[T]) error {
 	if node == nil {
-		fmt.Fprintln(os.Stderr, "Cannot add a nil node to ", *l, ".")
-		os.Exit(1)
+		return ErrNilNode
 	}
 	if l.n == 0 {
 		// List is empty
 		l.first = node
 		l.last = node
 		l.n = 1
-		return
+		return nil
 	}
 	l.last.next = node
 	node.prev = l.last
 	l.last = node
 	l.n++
+	return nil
 }
 
-func (l *LinkedList[T]) Unlink(node *ListNode[T]) {
+// Removes node from l.
+// Returns ErrNilNode if node is nil and ErrEmptyList if l is empty.
+func (l *LinkedList[T]) Unlink(node *ListNode[T]) error {
 	if node == nil {
-		fmt.Fprintln(os.Stderr, "Cannot unlink a nil node.")
-		os.Exit(1)
+		return ErrNilNode
 	}
 	if l.n == 0 {
 		// List is empty
-		fmt.Fprintln(os.Stderr, "Cannot unlink", *node, ". LinkedList ", *l, "is already empty.")
-		os.Exit(1)
+		return ErrEmptyList
 	}
 	if node.prev != nil {
 		node.prev.next = node.next
@@ -69,6 +70,7 @@ func (l *LinkedList[T]) Unlink(node *ListNode[T]) {
 	node.prev = nil
 	node.next = nil
 	l.n--
+	return nil
 }
 
 func (l *LinkedList[T]) Print() {
@@ -0,0 +1,149 @@
+//go:build unix && (amd64 || arm64 || loong64 || mips64le || ppc64le || riscv64)
+
+package fruit
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Opens an index written by Index.Save by mapping the file read-only into memory.
+// Queries read the scheme directly from the mapping, so opening the index only
+// touches the header and the vertex mappings. Processes mapping the same file
+// share it through the page cache.
+// The body checksum is not verified, use Load for a fully checked copy.
+// The index must be released with Close.
+func OpenMapped(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < indexHeaderSize {
+		return nil, fmt.Errorf("reading header of %s: %w", path, ErrIndexFormat)
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s: %w", path, err)
+	}
+	idx, err := mapIndex(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	idx.unmap = func() error {
+		return syscall.Munmap(data)
+	}
+	return idx, nil
+}
+
+// Returns the next count words of data as ints without copying them.
+// Relies on ints being 8 byte little-endian words on this platform.
+func wordsAt(data []byte, off, count int) ([]int, int) {
+	if count == 0 {
+		return []int{}, off
+	}
+	words := unsafe.Slice((*int)(unsafe.Pointer(&data[off])), count)
+	return words, off + 8*count
+}
+
+// Returns the next count words of T in data without copying them.
+func entriesAt[T schemeEntry | uint32](data []byte, off, count int) []T {
+	if count == 0 {
+		return []T{}
+	}
+	return unsafe.Slice((*T)(unsafe.Pointer(&data[off])), count)
+}
+
+// Returns a scheme whose entries point into data[off:] as written by Scheme.writeTo.
+// The scheme is read-only as the mapping is.
+func mapScheme[T schemeEntry](data []byte, off int, h indexHeader) (Scheme, error) {
+	rows, k, width := int(h.comps), int(h.chains), int(h.width)
+	if !h.sparse {
+		return &packedScheme[T]{rows, k, width, k, entriesAt[T](data, off, rows*k)}, nil
+	}
+	entries := int(h.entries)
+	offsets, off := wordsAt(data, off, rows+1)
+	chains := entriesAt[uint32](data, off, entries)
+	off += 4 * (entries + entries%2)
+	s := &sparseScheme[T]{
+		rows, k, width, offsets[:rows], offsets[1:], chains, entriesAt[T](data, off, entries), 0,
+	}
+	// Offsets are used as indices and chains are handed out to callers
+	for v := 0; v < rows; v++ {
+		if offsets[v] < 0 || offsets[v] > offsets[v+1] || offsets[v+1] > entries {
+			return nil, ErrIndexCorrupt
+		}
+	}
+	for _, c := range chains {
+		if int(c) >= k {
+			return nil, ErrIndexCorrupt
+		}
+	}
+	return s, nil
+}
+
+// Creates an index whose tables point into the mapped index file data.
+func mapIndex(data []byte) (*Index, error) {
+	h, err := decodeIndexHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	size, ok := h.bodySize()
+	if !ok || int64(len(data))-indexHeaderSize != size {
+		return nil, fmt.Errorf("%w: size does not match header", ErrIndexCorrupt)
+	}
+	n, comps, chains := int(h.n), int(h.comps), int(h.chains)
+	idx := &Index{
+		n: n,
+		m: int(h.m),
+		stats: BuildStats{
+			Nodes: n, Edges: int(h.m), SCCs: comps, Chains: chains,
+			SchemeWidth: int(h.width), SchemeSparse: h.sparse,
+		},
+	}
+	off := indexHeaderSize
+	idx.vToComp, off = wordsAt(data, off, n)
+	idx.vToIdTable, off = wordsAt(data, off, n)
+	idx.idTable, off = wordsAt(data, off, 2*int(h.ids))
+	idx.chainOf, off = wordsAt(data, off, comps)
+	idx.posOf, off = wordsAt(data, off, comps)
+	switch h.width {
+	case 1:
+		idx.scheme, err = mapScheme[uint8](data, off, h)
+	case 2:
+		idx.scheme, err = mapScheme[uint16](data, off, h)
+	case 4:
+		idx.scheme, err = mapScheme[uint32](data, off, h)
+	default:
+		idx.scheme, err = mapScheme[uint64](data, off, h)
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx.stats.SchemeEntries = idx.scheme.Entries()
+
+	// Entries used as indices must stay in range, scheme entries are only compared
+	for _, c := range idx.vToComp {
+		if c < 0 || c >= comps {
+			return nil, ErrIndexCorrupt
+		}
+	}
+	// Lookups binary search the IDs and use the vertices as indices
+	for i := 0; i < int(h.ids); i++ {
+		if v := idx.idTable[2*i+1]; v < 0 || v >= n || i > 0 && idx.idTable[2*i] <= idx.idTable[2*i-2] {
+			return nil, ErrIndexCorrupt
+		}
+	}
+	if !validChainPositions(idx.chainOf, idx.posOf, chains) {
+		return nil, ErrIndexCorrupt
+	}
+	return idx, nil
+}
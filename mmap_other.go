@@ -0,0 +1,9 @@
+//go:build !(unix && (amd64 || arm64 || loong64 || mips64le || ppc64le || riscv64))
+
+package fruit
+
+// Opens an index written by Index.Save.
+// Memory mapping is not supported on this platform, so the index is loaded with Load.
+func OpenMapped(path string) (*Index, error) {
+	return Load(path)
+}
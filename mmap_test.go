@@ -0,0 +1,50 @@
+//go:build unix && (amd64 || arm64 || loong64 || mips64le || ppc64le || riscv64)
+
+package fruit
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Checks that mapping rejects a corrupt ID table, whose checksum is not verified.
+func TestOpenMappedCorruptIDs(t *testing.T) {
+	idx := createTestIndex(readTestGraph("./test_graphs/collapse.gr", t), t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.fruit")
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := decodeIndexHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idOff := indexHeaderSize + 16*int(h.n)
+
+	corrupt := func(name string, change func(b []byte)) {
+		t.Run(name, func(t *testing.T) {
+			b := append([]byte(nil), data...)
+			change(b[idOff:])
+			p := filepath.Join(dir, name)
+			if err := os.WriteFile(p, b, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if mapped, err := OpenMapped(p); !errors.Is(err, ErrIndexCorrupt) {
+				if err == nil {
+					mapped.Close()
+				}
+				t.Fatalf("Expected %v, got %v", ErrIndexCorrupt, err)
+			}
+		})
+	}
+	corrupt("vertex", func(b []byte) { binary.LittleEndian.PutUint64(b[8:], 1<<40) })
+	corrupt("negative vertex", func(b []byte) { binary.LittleEndian.PutUint64(b[8:], 1<<63) })
+	corrupt("order", func(b []byte) { copy(b[16:24], b[0:8]) })
+}
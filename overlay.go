@@ -0,0 +1,284 @@
+package fruit
+
+import (
+	"maps"
+	"sync"
+)
+
+// OverlayIndex answers reachability queries on a graph that changes by
+// edge insertions and deletions without modifying its base index. Changed
+// edges are kept in a small overlay and combined with the base at query time.
+// Once the overlay holds threshold edges, a new base is built in the
+// background from the updated graph and swapped in.
+// It is safe for concurrent use.
+type OverlayIndex struct {
+	mu      sync.RWMutex
+	base    *Index
+	graph   *CSRGraph       // graph the base was built from
+	added   map[[2]int]bool // edges missing in the base graph
+	deleted map[[2]int]bool // edges of the base graph that were deleted
+
+	method     DecompMethod
+	threshold  int
+	compacting bool          // whether a compaction is running
+	log        []overlayEdit // edits since the running compaction started
+	compactMu  sync.Mutex    // held by the running compaction
+}
+
+type overlayEdit struct {
+	s, t   int
+	insert bool
+}
+
+// Builds the base index of g and an empty overlay that is compacted
+// once it holds threshold edges.
+// Returns ErrGraphSize if g has more than 2^31-1 vertices.
+func CreateOverlayIndex(g *Graph, method DecompMethod, threshold int) (*OverlayIndex, error) {
+	if err := checkCSRSize(g.n); err != nil {
+		return nil, err
+	}
+	c := g.csr()
+	base, err := CreateIndexFromCSR(c, method)
+	if err != nil {
+		return nil, err
+	}
+	return &OverlayIndex{
+		base:      base,
+		graph:     c,
+		added:     make(map[[2]int]bool),
+		deleted:   make(map[[2]int]bool),
+		method:    method,
+		threshold: max(threshold, 1),
+	}, nil
+}
+
+// Returns the number of edges in the overlay.
+func (o *OverlayIndex) Pending() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.added) + len(o.deleted)
+}
+
+// Returns whether the vertex with ID s can reach the vertex with ID t
+// in the current graph. The search only visits s and the targets of the
+// added edges A, connected by base paths that avoid the deleted edges D.
+// This takes O(|A|^2 * |D|) base queries, plus a search of the base graph
+// for each pair of them with a deleted edge in between, see avoidsDeleted.
+func (o *OverlayIndex) Reachable(s, t int) (bool, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	v, err := o.base.vertex(s)
+	if err != nil {
+		return false, err
+	}
+	w, err := o.base.vertex(t)
+	if err != nil {
+		return false, err
+	}
+	return o.reaches(v, w), nil
+}
+
+// Returns whether s reaches t through base paths and added edges.
+func (o *OverlayIndex) reaches(s, t int) bool {
+	seen := map[int]bool{s: true}
+	queue := []int{s}
+	for i := 0; i < len(queue); i++ {
+		v := queue[i]
+		if found, _ := o.avoidsDeleted(v, t); found {
+			return true
+		}
+		for e := range o.added {
+			if seen[e[1]] {
+				continue
+			}
+			if found, _ := o.avoidsDeleted(v, e[0]); found {
+				seen[e[1]] = true
+				queue = append(queue, e[1])
+			}
+		}
+	}
+	return false
+}
+
+// Returns whether s reaches t in the base graph without passing a deleted
+// edge, and the number of vertices whose edges were searched. Only deleted
+// edges (a, b) with s reaching a and b reaching t can cut the base paths.
+// If there are any, the edges of the base graph are searched from s, only
+// entering vertices that reach t, until a vertex reaches none of their
+// sources. So the search visits at most the vertices between s and these
+// sources, each in O(|D|) base queries.
+func (o *OverlayIndex) avoidsDeleted(s, t int) (bool, int) {
+	if s == t {
+		return true, 0
+	}
+	if !o.base.reachable(s, t) {
+		return false, 0
+	}
+	var cut [][2]int
+	for e := range o.deleted {
+		if o.base.reachable(s, e[0]) && o.base.reachable(e[1], t) {
+			cut = append(cut, e)
+		}
+	}
+	clean := func(v int) bool {
+		for _, e := range cut {
+			if o.base.reachable(v, e[0]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	searched := 0
+	seen := map[int]bool{s: true}
+	stack := []int{s}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if v == t || clean(v) {
+			return true, searched
+		}
+		searched++
+		for _, w := range o.graph.outs(v) {
+			if !seen[int(w)] && !o.deleted[[2]int{v, int(w)}] && o.base.reachable(int(w), t) {
+				seen[int(w)] = true
+				stack = append(stack, int(w))
+			}
+		}
+	}
+	return false, searched
+}
+
+// Inserts an edge from the vertex with ID u to the vertex with ID v into
+// the overlay. Inserting an existing edge does nothing.
+func (o *OverlayIndex) InsertEdge(u, v int) error {
+	return o.edit(u, v, true)
+}
+
+// Deletes the edge from the vertex with ID u to the vertex with ID v
+// through the overlay. Returns an UnknownEdgeError if there is no such edge.
+func (o *OverlayIndex) DeleteEdge(u, v int) error {
+	return o.edit(u, v, false)
+}
+
+func (o *OverlayIndex) edit(u, v int, insert bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s, err := o.base.vertex(u)
+	if err != nil {
+		return err
+	}
+	t, err := o.base.vertex(v)
+	if err != nil {
+		return err
+	}
+	if !o.apply(overlayEdit{s, t, insert}) {
+		return &UnknownEdgeError{u, v}
+	}
+	if o.compacting {
+		o.log = append(o.log, overlayEdit{s, t, insert})
+	} else if len(o.added)+len(o.deleted) >= o.threshold {
+		o.compacting = true
+		go o.Compact()
+	}
+	return nil
+}
+
+// Applies the edit to the overlay. Returns false for a deleted edge that does not exist.
+func (o *OverlayIndex) apply(edit overlayEdit) bool {
+	e := [2]int{edit.s, edit.t}
+	inBase := o.graph.hasEdge(edit.s, edit.t)
+	switch {
+	case edit.insert && o.deleted[e]:
+		delete(o.deleted, e)
+	case edit.insert && !inBase:
+		o.added[e] = true
+	case !edit.insert && o.added[e]:
+		delete(o.added, e)
+	case !edit.insert && inBase && !o.deleted[e]:
+		o.deleted[e] = true
+	case !edit.insert:
+		return false
+	}
+	return true
+}
+
+// Builds a new base index from the current graph and swaps it in, keeping
+// the edits made in the meantime in the overlay. Queries and edits use the
+// old base until then. Waits for a compaction running in the background.
+func (o *OverlayIndex) Compact() error {
+	o.compactMu.Lock()
+	defer o.compactMu.Unlock()
+
+	o.mu.Lock()
+	graph, added, deleted := o.graph, maps.Clone(o.added), maps.Clone(o.deleted)
+	o.compacting = len(added)+len(deleted) > 0
+	o.log = nil
+	o.mu.Unlock()
+	if len(added)+len(deleted) == 0 {
+		return nil
+	}
+
+	updated := graph.withEdits(added, deleted)
+	base, err := CreateIndexFromCSR(updated, o.method)
+	if err != nil {
+		o.mu.Lock()
+		o.compacting = false
+		o.log = nil
+		o.mu.Unlock()
+		return err
+	}
+	o.swap(base, updated)
+	return nil
+}
+
+// Replaces the base by the index built from graph and replays the edits
+// logged since the compaction started on an empty overlay. Starts the next
+// compaction if they fill the overlay again.
+func (o *OverlayIndex) swap(base *Index, graph *CSRGraph) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.base = base
+	o.graph = graph
+	o.added = make(map[[2]int]bool)
+	o.deleted = make(map[[2]int]bool)
+	for _, edit := range o.log {
+		o.apply(edit)
+	}
+	o.log = nil
+	o.compacting = len(o.added)+len(o.deleted) >= o.threshold
+	if o.compacting {
+		go o.Compact()
+	}
+}
+
+// Returns whether g has an edge from s to t in O(out-degree of s).
+func (g *CSRGraph) hasEdge(s, t int) bool {
+	for _, w := range g.outs(s) {
+		if int(w) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a copy of g with the added edges and without the deleted ones.
+func (g *CSRGraph) withEdits(added, deleted map[[2]int]bool) *CSRGraph {
+	var sources, targets []int32
+	for v := 0; v < g.n; v++ {
+		for _, w := range g.outs(v) {
+			if !deleted[[2]int{v, int(w)}] {
+				sources = append(sources, int32(v))
+				targets = append(targets, w)
+			}
+		}
+	}
+	for e := range added {
+		sources = append(sources, int32(e[0]))
+		targets = append(targets, int32(e[1]))
+	}
+	h := createCSRGraphFromEdges(g.n, sources, targets)
+	h.idMapping = g.idMapping
+	h.options = g.options
+	return h
+}
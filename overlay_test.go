@@ -0,0 +1,220 @@
+package fruit
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOverlayIndex(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			ids := make([]int, 0, len(g.idMapping.idToV))
+			for id := range g.idMapping.idToV {
+				ids = append(ids, id)
+			}
+			slices.Sort(ids)
+			// Edges of the current graph by IDs
+			edges := make(map[[2]int]bool)
+			for v := 0; v < g.n; v++ {
+				for e := g.nodes[v].out; e != nil; e = e.next {
+					edges[[2]int{g.idMapping.vToId[v], g.idMapping.vToId[e.target]}] = true
+				}
+			}
+			o, err := CreateOverlayIndex(g, H3Concat, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rng := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 40; i++ {
+				u, v := ids[rng.Intn(len(ids))], ids[rng.Intn(len(ids))]
+				if rng.Intn(2) == 0 && len(edges) > 0 {
+					keys := make([][2]int, 0, len(edges))
+					for e := range edges {
+						keys = append(keys, e)
+					}
+					slices.SortFunc(keys, func(a, b [2]int) int {
+						if a[0] != b[0] {
+							return a[0] - b[0]
+						}
+						return a[1] - b[1]
+					})
+					e := keys[rng.Intn(len(keys))]
+					u, v = e[0], e[1]
+					if err := o.DeleteEdge(u, v); err != nil {
+						t.Fatal(err)
+					}
+					delete(edges, [2]int{u, v})
+				} else {
+					if err := o.InsertEdge(u, v); err != nil {
+						t.Fatal(err)
+					}
+					edges[[2]int{u, v}] = true
+				}
+				if i%10 == 9 {
+					if err := o.Compact(); err != nil {
+						t.Fatal(err)
+					}
+					if n := o.Pending(); n != 0 {
+						t.Fatalf("Overlay holds %d edges after compaction", n)
+					}
+				}
+
+				out := make(map[int][]int)
+				for e := range edges {
+					out[e[0]] = append(out[e[0]], e[1])
+				}
+				for sID := range g.idMapping.idToV {
+					reached := map[int]bool{sID: true}
+					queue := []int{sID}
+					for j := 0; j < len(queue); j++ {
+						for _, wID := range out[queue[j]] {
+							if !reached[wID] {
+								reached[wID] = true
+								queue = append(queue, wID)
+							}
+						}
+					}
+					for wID := range g.idMapping.idToV {
+						if reachable, err := o.Reachable(sID, wID); err != nil || reachable != reached[wID] {
+							t.Fatalf("Reachable(%d, %d) = %t, %v after %d edits, want %t", sID, wID, reachable, err, i+1, reached[wID])
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestOverlayIndexConcurrent(t *testing.T) {
+	g := readTestGraph("./data/gnm/gnm_100_100.gr", t)
+	o, err := CreateOverlayIndex(g, H3Concat, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for i := 0; i < 200; i++ {
+				if _, err := o.Reachable(rng.Intn(100), rng.Intn(100)); err != nil {
+					var unknownErr *UnknownVertexError
+					if !errors.As(err, &unknownErr) {
+						t.Error(err)
+					}
+				}
+			}
+		}(int64(r))
+	}
+	// New edges are inserted and deleted again while compactions run in the background
+	var inserted [][2]int
+	c := g.csr()
+	for i := 0; i < 40; i++ {
+		u, v := g.idMapping.vToId[i], g.idMapping.vToId[i+1]
+		if !c.hasEdge(i, i+1) {
+			if err := o.InsertEdge(u, v); err != nil {
+				t.Fatal(err)
+			}
+			inserted = append(inserted, [2]int{u, v})
+		}
+	}
+	for _, e := range inserted {
+		if err := o.DeleteEdge(e[0], e[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if err := o.Compact(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := g.dfsCreateMatrix()
+	for s, sID := range g.idMapping.vToId {
+		for w, wID := range g.idMapping.vToId {
+			if reachable, err := o.Reachable(sID, wID); err != nil || reachable != m[s][w] {
+				t.Fatalf("Reachable(%d, %d) = %t, %v, want %t", sID, wID, reachable, err, m[s][w])
+			}
+		}
+	}
+	var edgeErr *UnknownEdgeError
+	if err := o.DeleteEdge(inserted[0][0], inserted[0][1]); !errors.As(err, &edgeErr) {
+		t.Errorf("Deleting a missing edge returned %v", err)
+	}
+}
+
+func TestOverlaySearchBound(t *testing.T) {
+	// Path 0 -> ... -> 199 with a shortcut from 150 to 199 and the edge
+	// from 150 to 151 deleted, vertex v has ID v+1
+	g := createPathGraph(200)
+	g.AddEdge(&Edge{source: 150, target: 199})
+	o, err := CreateOverlayIndex(g, H3Concat, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.DeleteEdge(151, 152); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		s, t     int
+		want     bool
+		searched int // vertices between s and the deleted edge
+	}{
+		{100, 199, true, 51},
+		{100, 175, false, 51},
+		{160, 199, true, 0},
+		{10, 120, true, 0},
+		{151, 199, true, 0},
+	}
+	for _, c := range cases {
+		found, searched := o.avoidsDeleted(c.s, c.t)
+		if found != c.want || searched > c.searched {
+			t.Errorf("avoidsDeleted(%d, %d) = %t after searching %d vertices, want %t after at most %d",
+				c.s, c.t, found, searched, c.want, c.searched)
+		}
+		if reachable, err := o.Reachable(c.s+1, c.t+1); err != nil || reachable != c.want {
+			t.Errorf("Reachable(%d, %d) = %t, %v, want %t", c.s+1, c.t+1, reachable, err, c.want)
+		}
+	}
+}
+
+func TestOverlayCompactionRestart(t *testing.T) {
+	g := createPathGraph(20)
+	o, err := CreateOverlayIndex(g, H3Concat, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Edits logged while a compaction runs fill the overlay past the threshold
+	o.mu.Lock()
+	o.compacting = true
+	o.log = []overlayEdit{{19, 0, true}, {10, 2, true}, {7, 3, true}}
+	o.mu.Unlock()
+	o.swap(o.base, o.graph)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for o.Pending() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Overlay still holds %d edges, no compaction was started", o.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := o.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if reachable, err := o.Reachable(20, 1); err != nil || !reachable {
+		t.Errorf("Reachable(20, 1) = %t, %v after compaction, want true", reachable, err)
+	}
+}
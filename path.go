@@ -0,0 +1,109 @@
+package fruit
+
+import (
+	"errors"
+)
+
+var ErrNoGraph = errors.New("index has no graph, it was loaded from a file or built from a CSRGraph")
+
+// Returns the IDs of a path from the vertex with ID s to the vertex with ID t
+// or nil if s cannot reach t.
+// The walk through the condensed graph only follows edges to components that
+// still reach t, so it never backtracks. Each component on the way is crossed
+// by a breadth-first search restricted to its vertices.
+// Needs the graph the index was built from.
+func (idx *Index) Path(s, t int) ([]int, error) {
+	if idx.src == nil {
+		return nil, ErrNoGraph
+	}
+	v, err := idx.vertex(s)
+	if err != nil {
+		return nil, err
+	}
+	w, err := idx.vertex(t)
+	if err != nil {
+		return nil, err
+	}
+	path := idx.path(v, w)
+	for i, v := range path {
+		path[i], _ = idx.ID(v)
+	}
+	return path, nil
+}
+
+// Returns the vertices of a path from s to t or nil if s cannot reach t.
+func (idx *Index) path(s, t int) []int {
+	if !idx.reachable(s, t) {
+		return nil
+	}
+	comps := idx.compPath(idx.vToComp[s], idx.vToComp[t])
+
+	var path []int
+	v := s
+	for i := 0; i+1 < len(comps); i++ {
+		u, w := idx.crossingEdge(comps[i], comps[i+1])
+		path = append(path, idx.pathInComp(v, u)...)
+		v = w
+	}
+	return append(path, idx.pathInComp(v, t)...)
+}
+
+// Returns the components on a path from component s to component t,
+// which must be reachable. Runs in O(sum of out-degrees on the path).
+func (idx *Index) compPath(s, t int) []int {
+	path := []int{s}
+	for c := s; c != t; {
+		next := -1
+		idx.eachOutComp(c, func(d int) {
+			if next < 0 && idx.compReaches(d, t) {
+				next = d
+			}
+		})
+		c = next
+		path = append(path, c)
+	}
+	return path
+}
+
+// Returns an edge (u, w) of the input graph from component c to component d.
+func (idx *Index) crossingEdge(c, d int) (int, int) {
+	for _, u := range idx.compMembers()[c] {
+		for e := idx.src.nodes[u].out; e != nil; e = e.next {
+			if idx.vToComp[e.target] == d {
+				return u, e.target
+			}
+		}
+	}
+	return -1, -1
+}
+
+// Returns a shortest path from s to t inside their common component
+// or nil if there is none.
+func (idx *Index) pathInComp(s, t int) []int {
+	comp := idx.vToComp[s]
+	pre := map[int]int{s: s}
+	queue := []int{s}
+	for len(queue) > 0 && queue[0] != t {
+		v := queue[0]
+		queue = queue[1:]
+		for e := idx.src.nodes[v].out; e != nil; e = e.next {
+			_, seen := pre[e.target]
+			if !seen && idx.vToComp[e.target] == comp {
+				pre[e.target] = v
+				queue = append(queue, e.target)
+			}
+		}
+	}
+	if _, found := pre[t]; !found {
+		return nil
+	}
+	path := []int{t}
+	for v := t; v != s; v = pre[v] {
+		path = append(path, pre[v])
+	}
+	// Reverse path
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,67 @@
+package fruit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./test_graphs/concat.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			m := g.dfsCreateMatrix()
+			edges := make(map[[2]int]bool)
+			for v := 0; v < g.n; v++ {
+				for e := g.nodes[v].out; e != nil; e = e.next {
+					edges[[2]int{g.idMapping.vToId[v], g.idMapping.vToId[e.target]}] = true
+				}
+			}
+			idx := createTestIndex(g, t)
+
+			for s, sID := range g.idMapping.vToId {
+				for w, wID := range g.idMapping.vToId {
+					path, err := idx.Path(sID, wID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if (path != nil) != m[s][w] {
+						t.Fatalf("Path(%d, %d) = %v, reachable %t", sID, wID, path, m[s][w])
+					}
+					if path == nil {
+						continue
+					}
+					if path[0] != sID || path[len(path)-1] != wID {
+						t.Fatalf("Path(%d, %d) = %v has wrong ends", sID, wID, path)
+					}
+					for i := 0; i+1 < len(path); i++ {
+						if !edges[[2]int{path[i], path[i+1]}] {
+							t.Fatalf("Path(%d, %d) = %v uses missing edge %d -> %d", sID, wID, path, path[i], path[i+1])
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPathWithoutGraph(t *testing.T) {
+	g := readTestGraph("./test_graphs/collapse.gr", t)
+	path := t.TempDir() + "/collapse.idx"
+	if err := createTestIndex(g, t).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Path(1, 1); !errors.Is(err, ErrNoGraph) {
+		t.Errorf("Path on loaded index returned %v, want %v", err, ErrNoGraph)
+	}
+}
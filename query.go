@@ -0,0 +1,49 @@
+package fruit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Answers the reachability queries read from r, one pair "source target" of
+// IDs of the input file per line, by writing "source target true|false" lines
+// to w. Blank lines are skipped. A query with an unknown ID is answered by a
+// line "source target error: <reason>" and the remaining queries are still
+// answered, the failed ones are then returned as a BatchError of QueryErrors.
+// Stops at the first malformed line with a ParseError. Errors refer to r as name.
+func (idx *Index) AnswerQueries(r io.Reader, w io.Writer, name string) error {
+	scanner := bufio.NewScanner(r)
+	out := bufio.NewWriter(w)
+	var failed []error
+	sourceID, targetID := -1, -1
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if _, err := fmt.Sscanf(line, "%d %d", &sourceID, &targetID); err != nil {
+			out.Flush()
+			return &ParseError{name, lineNr, line, err}
+		}
+		reachable, err := idx.Reachable(sourceID, targetID)
+		if err != nil {
+			fmt.Fprintf(out, "%d %d error: %v\n", sourceID, targetID, err)
+			failed = append(failed, &QueryError{name, lineNr, err})
+			continue
+		}
+		fmt.Fprintln(out, sourceID, targetID, reachable)
+	}
+	if err := scanner.Err(); err != nil {
+		out.Flush()
+		return fmt.Errorf("reading queries of %s: %w", name, err)
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return &BatchError{name, failed}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package fruit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAnswerQueries(t *testing.T) {
+	g := readTestGraph("./test_graphs/collapse.gr", t)
+	idx := createTestIndex(g, t)
+
+	var out strings.Builder
+	if err := idx.AnswerQueries(strings.NewReader("2 14\n\n14 2\n13 13\n"), &out, "stdin"); err != nil {
+		t.Fatal(err)
+	}
+	want := "2 14 true\n14 2 false\n13 13 true\n"
+	if out.String() != want {
+		t.Errorf("AnswerQueries wrote %q, want %q", out.String(), want)
+	}
+
+	// Unknown IDs in the middle of the batch fail only their own queries
+	out.Reset()
+	err := idx.AnswerQueries(strings.NewReader("2 14\n2 99\n14 2\n98 2\n13 13\n"), &out, "stdin")
+	var batchErr *BatchError
+	var parseErr *ParseError
+	var queryErr *QueryError
+	var unknownErr *UnknownVertexError
+	if !errors.As(err, &batchErr) || len(batchErr.Errs) != 2 {
+		t.Fatalf("AnswerQueries with unknown IDs returned %v", err)
+	}
+	if !errors.As(err, &queryErr) || queryErr.Line != 2 || !errors.As(err, &unknownErr) || unknownErr.ID != 99 {
+		t.Errorf("AnswerQueries reported the first unknown ID as %v", err)
+	}
+	if msg := batchErr.Errs[0].Error(); msg != "stdin:2: unknown vertex ID 99" {
+		t.Errorf("AnswerQueries reported the first unknown ID as %q", msg)
+	}
+	if !errors.As(batchErr.Errs[1], &queryErr) || queryErr.Line != 4 || !errors.As(batchErr.Errs[1], &unknownErr) || unknownErr.ID != 98 {
+		t.Errorf("AnswerQueries reported the second unknown ID as %v", batchErr.Errs[1])
+	}
+	want = "2 14 true\n2 99 error: unknown vertex ID 99\n14 2 false\n98 2 error: unknown vertex ID 98\n13 13 true\n"
+	if out.String() != want {
+		t.Errorf("AnswerQueries wrote %q, want %q", out.String(), want)
+	}
+
+	err = idx.AnswerQueries(strings.NewReader("2 x\n"), &out, "stdin")
+	if !errors.As(err, &parseErr) || parseErr.Line != 1 {
+		t.Errorf("AnswerQueries with malformed line returned %v", err)
+	}
+}
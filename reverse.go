@@ -0,0 +1,126 @@
+package fruit
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var ErrNoReverse = errors.New("index has no reverse scheme, it is created by CreateReverse")
+
+// Returns a decomposition with the same chains, each in reversed order.
+func (decomp *Decomposition) reverse() *Decomposition {
+	rev := &Decomposition{make([]ChainMapping, len(decomp.vToChain)), createLinkedList[Chain]()}
+	for cNode := decomp.chains.first; cNode != nil; cNode = cNode.next {
+		rNode := createListNode(createChain(cNode.val.id))
+		entries := getEntries(rNode)
+		for vNode := getLast(cNode); vNode != nil; vNode = vNode.prev {
+			rev.vToChain[vNode.val] = ChainMapping{rNode, entries.n}
+			entries.Add(createListNode(vNode.val))
+		}
+		rev.chains.Add(rNode)
+	}
+	return rev
+}
+
+// Creates the reverse scheme used by ancestor queries in O(|E| * k_c).
+// For every component and chain it stores the highest position in the chain
+// that reaches the component. It is the indexing scheme of the reversed
+// condensed graph with the same chains reversed, so the highest position
+// becomes the lowest. An index without graph derives it from its own scheme
+// in O(|V_c| * k_c * log L) queries instead, where L is the longest chain.
+func (idx *Index) CreateReverse() error {
+	idx.buildTables()
+	if idx.src == nil {
+		idx.reverse = idx.deriveReverse()
+		return nil
+	}
+	r := idx.src.csr().condense(idx.vToComp, idx.members, &BuildStats{}).reverse()
+	topo := r.TopoSort()
+	r.TopoSortOutEdges(topo)
+	idx.reverse = r.CreateIndexingScheme(topo, idx.decomposition().reverse())
+	return nil
+}
+
+// Returns the reverse scheme computed from the reachability queries of the
+// index. Every component on a chain reaches the next one, so the components
+// reaching c form a prefix of the chain, whose end is found by binary search.
+func (idx *Index) deriveReverse() Scheme {
+	k := idx.scheme.Chains()
+	reverse := createSchemeWithLayout(len(idx.chainOf), k, idx.scheme.Width(), idx.scheme.Sparse())
+	row := make([]int, k)
+	for c := range idx.chainOf {
+		for chain, comps := range idx.chainComps {
+			base := idx.chainBase[chain]
+			end := base + sort.Search(len(comps)-base, func(i int) bool {
+				d := comps[base+i]
+				return d == c || !idx.compReaches(d, c)
+			})
+			// Positions in the reversed chain count from its end
+			row[chain] = math.MaxInt
+			if end > base {
+				row[chain] = len(comps) - end
+			}
+		}
+		reverse.setRow(c, row)
+	}
+	return reverse
+}
+
+// Calls f with the ID of every vertex other than the vertex with ID t that
+// can reach it, in no particular order, until f returns false.
+// Reads the ancestors straight from the chains like Descendants,
+// in O(k + number of ancestors) for a dense reverse scheme.
+// Returns ErrNoReverse if CreateReverse was not called.
+func (idx *Index) Ancestors(t int, f func(s int) bool) error {
+	if idx.reverse == nil {
+		return ErrNoReverse
+	}
+	v, err := idx.vertex(t)
+	if err != nil {
+		return err
+	}
+	idx.ancestors(v, idx.withIDs(f))
+	return nil
+}
+
+// Calls f for every vertex other than t that can reach t until f returns false.
+func (idx *Index) ancestors(t int, f func(s int) bool) {
+	idx.buildTables()
+	comp := idx.vToComp[t]
+	if !idx.eachMember(comp, t, f) {
+		return
+	}
+	done := false
+	idx.reverse.eachEntry(comp, func(chain, pos int) {
+		comps := idx.chainComps[chain]
+		base := idx.chainBase[chain]
+		for _, c := range comps[base:max(len(comps)-pos, base)] {
+			if done || !idx.eachMember(c, t, f) {
+				done = true
+				return
+			}
+		}
+	})
+}
+
+// Returns the number of vertices other than the vertex with ID t that can
+// reach it in O(k). Returns ErrNoReverse if CreateReverse was not called.
+func (idx *Index) AncestorCount(t int) (int, error) {
+	if idx.reverse == nil {
+		return 0, ErrNoReverse
+	}
+	v, err := idx.vertex(t)
+	if err != nil {
+		return 0, err
+	}
+	idx.buildTables()
+	comp := idx.vToComp[v]
+	count := len(idx.members[comp]) - 1
+	idx.reverse.eachEntry(comp, func(chain, pos int) {
+		sizes := idx.chainSizes[chain]
+		base := idx.chainBase[chain]
+		count += sizes[max(len(sizes)-1-pos, base)] - sizes[base]
+	})
+	return count, nil
+}
@@ -0,0 +1,317 @@
+package fruit
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Color of the vertices whose component is found
+const sccDone = -1
+
+// State of the forward-backward algorithm shared by its goroutines.
+// Every set of undecided vertices has a color of its own, so goroutines
+// working on different sets never write the same entries.
+type fbState struct {
+	g         *CSRGraph
+	color     []atomic.Int64
+	fwd       []int64 // color of the set whose forward search reached the vertex
+	bwd       []int64 // color of the set whose backward search reached the vertex
+	inDeg     []atomic.Int32
+	outDeg    []atomic.Int32
+	rep       []int // vertex representing the component
+	colors    atomic.Int64
+	nodes     atomic.Uint64
+	edges     atomic.Uint64
+	mu        sync.Mutex
+	cond      *sync.Cond
+	tasks     [][]int // sets of undecided vertices
+	splitting int     // number of sets being split
+}
+
+// Finds the strongly connected components of g like FindSCCs with the
+// forward-backward algorithm on the given number of goroutines.
+// Vertices without incoming or outgoing edges are trimmed first as
+// components of their own. The forward and backward search from a pivot
+// then split the other vertices into its component and three sets that
+// share no component, which are split independently.
+// Components are numbered by their smallest vertex.
+// Takes O(|V| * (|V|+|E|)) in the worst case.
+func (g *CSRGraph) findSCCsParallel(stats *BuildStats, workers int) ([]int, [][]int) {
+	workers = max(workers, 1)
+	fb := &fbState{
+		g:      g,
+		color:  make([]atomic.Int64, g.n),
+		fwd:    make([]int64, g.n),
+		bwd:    make([]int64, g.n),
+		inDeg:  make([]atomic.Int32, g.n),
+		outDeg: make([]atomic.Int32, g.n),
+		rep:    make([]int, g.n),
+	}
+	fb.cond = sync.NewCond(&fb.mu)
+	fb.colors.Store(1)
+	all := make([]int, g.n)
+	for v := range all {
+		all[v] = v
+		fb.color[v].Store(1)
+	}
+	if rest := fb.trim(all, 1, workers); len(rest) > 0 {
+		fb.tasks = append(fb.tasks, rest)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fb.work()
+		}()
+	}
+	wg.Wait()
+	stats.CollapseNodes += uint(fb.nodes.Load())
+	stats.CollapseEdges += uint(fb.edges.Load())
+
+	compOf := make([]int, g.n) // component of each representative
+	for v := range compOf {
+		compOf[v] = -1
+	}
+	vToComp := make([]int, g.n)
+	compToV := make([][]int, 0)
+	for v := 0; v < g.n; v++ {
+		stats.CollapseNodes++
+		r := fb.rep[v]
+		if compOf[r] == -1 {
+			compOf[r] = len(compToV)
+			compToV = append(compToV, nil)
+		}
+		vToComp[v] = compOf[r]
+		compToV[compOf[r]] = append(compToV[compOf[r]], v)
+	}
+	return vToComp, compToV
+}
+
+// Splits sets of undecided vertices until all components are found.
+func (fb *fbState) work() {
+	fb.mu.Lock()
+	for {
+		for len(fb.tasks) == 0 && fb.splitting > 0 {
+			fb.cond.Wait()
+		}
+		if len(fb.tasks) == 0 {
+			fb.mu.Unlock()
+			return
+		}
+		set := fb.tasks[len(fb.tasks)-1]
+		fb.tasks = fb.tasks[:len(fb.tasks)-1]
+		fb.splitting++
+		fb.mu.Unlock()
+
+		parts := fb.split(set)
+
+		fb.mu.Lock()
+		fb.splitting--
+		fb.tasks = append(fb.tasks, parts...)
+		if len(parts) > 0 || fb.splitting == 0 {
+			fb.cond.Broadcast()
+		}
+	}
+}
+
+// Finds the component of the first vertex of the set and returns the
+// trimmed sets of vertices only reached forward, only reached backward
+// and not reached at all, each with a new color.
+func (fb *fbState) split(set []int) [][]int {
+	pivot := set[0]
+	c := fb.color[pivot].Load()
+	if len(set) < minParallel {
+		fb.search(pivot, c, true)
+		fb.search(pivot, c, false)
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fb.search(pivot, c, false)
+		}()
+		fb.search(pivot, c, true)
+		wg.Wait()
+	}
+
+	parts := make([][]int, 3)
+	for _, v := range set {
+		forward, backward := fb.fwd[v] == c, fb.bwd[v] == c
+		switch {
+		case forward && backward:
+			fb.rep[v] = pivot
+			fb.color[v].Store(sccDone)
+		case forward:
+			parts[0] = append(parts[0], v)
+		case backward:
+			parts[1] = append(parts[1], v)
+		default:
+			parts[2] = append(parts[2], v)
+		}
+	}
+	rest := parts[:0]
+	for _, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		partColor := fb.colors.Add(1)
+		for _, v := range part {
+			fb.color[v].Store(partColor)
+		}
+		if part = fb.trim(part, partColor, 1); len(part) > 0 {
+			rest = append(rest, part)
+		}
+	}
+	return rest
+}
+
+// Marks the vertices of color c that the pivot reaches, or that reach the
+// pivot if not forward, with c in O(|V_c| + |E_c|).
+func (fb *fbState) search(pivot int, c int64, forward bool) {
+	mark := fb.bwd
+	if forward {
+		mark = fb.fwd
+	}
+	mark[pivot] = c
+	queue := []int{pivot}
+	edges := uint64(0)
+	for i := 0; i < len(queue); i++ {
+		next := fb.g.ins(queue[i])
+		if forward {
+			next = fb.g.outs(queue[i])
+		}
+		for _, w := range next {
+			edges++
+			// Check the color first, other goroutines own the marks of other colors
+			if fb.color[w].Load() == c && mark[w] != c {
+				mark[w] = c
+				queue = append(queue, int(w))
+			}
+		}
+	}
+	fb.nodes.Add(uint64(len(queue)))
+	fb.edges.Add(edges)
+}
+
+// Repeatedly removes the vertices of color c without incoming or outgoing
+// edges inside the set as components of their own on the given number of
+// goroutines. Returns the remaining vertices in O(|V_c| + |E_c|).
+func (fb *fbState) trim(set []int, c int64, workers int) []int {
+	g := fb.g
+	work := make([][2]uint64, workers) // vertices and edges visited by each goroutine
+	next := make([][]int, workers)
+	parallelFor(len(set), workers, func(w, i int) {
+		v := set[i]
+		in, out := int32(0), int32(0)
+		for _, t := range g.outs(v) {
+			if fb.color[t].Load() == c {
+				out++
+			}
+		}
+		for _, s := range g.ins(v) {
+			if fb.color[s].Load() == c {
+				in++
+			}
+		}
+		fb.inDeg[v].Store(in)
+		fb.outDeg[v].Store(out)
+		work[w][0]++
+		work[w][1] += uint64(g.inDeg(v) + g.outDeg(v))
+	})
+	parallelFor(len(set), workers, func(w, i int) {
+		v := set[i]
+		if (fb.inDeg[v].Load() == 0 || fb.outDeg[v].Load() == 0) && fb.color[v].CompareAndSwap(c, sccDone) {
+			next[w] = append(next[w], v)
+		}
+	})
+
+	// Removing a vertex lowers the degrees of its neighbors
+	for {
+		var frontier []int
+		for w := range next {
+			frontier = append(frontier, next[w]...)
+			next[w] = next[w][:0]
+		}
+		if len(frontier) == 0 {
+			break
+		}
+		parallelFor(len(frontier), workers, func(w, i int) {
+			v := frontier[i]
+			fb.rep[v] = v
+			for _, t := range g.outs(v) {
+				if fb.color[t].Load() == c && fb.inDeg[t].Add(-1) == 0 && fb.color[t].CompareAndSwap(c, sccDone) {
+					next[w] = append(next[w], int(t))
+				}
+			}
+			for _, s := range g.ins(v) {
+				if fb.color[s].Load() == c && fb.outDeg[s].Add(-1) == 0 && fb.color[s].CompareAndSwap(c, sccDone) {
+					next[w] = append(next[w], int(s))
+				}
+			}
+			work[w][0]++
+			work[w][1] += uint64(g.inDeg(v) + g.outDeg(v))
+		})
+	}
+	for _, counts := range work {
+		fb.nodes.Add(counts[0])
+		fb.edges.Add(counts[1])
+	}
+
+	rest := set[:0:0]
+	for _, v := range set {
+		if fb.color[v].Load() == c {
+			rest = append(rest, v)
+		}
+	}
+	return rest
+}
+
+// Creates the same graph as condense on the given number of goroutines.
+// The distinct targets of each component are collected in parallel
+// and stored in the order of the components.
+func (g *CSRGraph) condenseParallel(vToComp []int, compToV [][]int, stats *BuildStats, workers int) *CSRGraph {
+	targets := make([][]int32, len(compToV))
+	collision := make([][]bool, workers)
+	for w := range collision {
+		collision[w] = make([]bool, len(compToV))
+	}
+	parallelFor(len(compToV), workers, func(w, compNr int) {
+		for _, v := range compToV[compNr] {
+			for _, t := range g.outs(v) {
+				tCompNr := vToComp[t]
+				if compNr != tCompNr && !collision[w][tCompNr] {
+					targets[compNr] = append(targets[compNr], int32(tCompNr))
+					collision[w][tCompNr] = true
+				}
+			}
+		}
+		// Clear reached-indices for the next component
+		for _, tCompNr := range targets[compNr] {
+			collision[w][tCompNr] = false
+		}
+	})
+
+	// Rows list the targets in reversed order like the sequential condensation
+	outStart := make([]int, len(compToV)+1)
+	for compNr, comp := range compToV {
+		for _, v := range comp {
+			stats.CollapseNodes++
+			stats.CollapseEdges += uint(g.outDeg(v))
+		}
+		outStart[compNr+1] = outStart[compNr] + len(targets[compNr])
+	}
+	out := make([]int32, outStart[len(compToV)])
+	for compNr, compTargets := range targets {
+		for i, tCompNr := range compTargets {
+			out[outStart[compNr+1]-1-i] = tCompNr
+		}
+	}
+	gPrime := createCSRGraph(len(compToV), outStart, out)
+	gPrime.stats = stats
+	gPrime.vToComp = vToComp
+	gPrime.idMapping = g.idMapping
+	gPrime.options = g.options
+	return gPrime
+}
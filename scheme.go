@@ -0,0 +1,322 @@
+package fruit
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// Scheme stores the indexing scheme: for every component and chain the
+// lowest position in the chain the component reaches.
+// Get returns math.MaxInt for chains the component does not reach.
+type Scheme interface {
+	Rows() int
+	Chains() int
+	// Bytes per stored position.
+	Width() int
+	// Number of stored entries.
+	Entries() int
+	// Whether only the finite entries are stored.
+	Sparse() bool
+	Get(v, c int) int
+
+	// Calls f for every finite entry of the row of v in increasing chain order.
+	eachEntry(v int, f func(c, pos int))
+
+	// Sets the entry of v for chain c to pos if pos is lower.
+	lower(v, c, pos int)
+	// Replaces the row of v by the given entries, math.MaxInt for unreached chains.
+	setRow(v int, row []int)
+	// Adds the given number of unreached rows and chains in amortized O(1) per row or chain.
+	grow(rows, chains int)
+	// Lowers the row of v to the entry-wise minimum with the row of w.
+	mergeRow(v, w int)
+	// Writes all entries row by row as little-endian words of Width bytes.
+	writeTo(w io.Writer) error
+}
+
+type schemeEntry interface {
+	~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// Dense row-major scheme storing every entry with the width of T.
+// The maximum value of T marks unreachable chains. Rows start every
+// stride entries, the entries after the first k of a row are unused.
+type packedScheme[T schemeEntry] struct {
+	rows   int
+	k      int
+	width  int
+	stride int
+	data   []T
+}
+
+// Returns whether the scheme entry marks an unreachable chain.
+func isInfinite(entry int) bool {
+	return entry == math.MaxInt
+}
+
+// Returns the smallest entry width in bytes that can store all
+// positions of a chain with maxLen vertices next to the infinity sentinel.
+func schemeWidthFor(maxLen int) int {
+	switch {
+	case maxLen <= math.MaxUint8:
+		return 1
+	case maxLen <= math.MaxUint16:
+		return 2
+	case uint64(maxLen) <= math.MaxUint32:
+		return 4
+	}
+	return 8
+}
+
+// Creates a scheme of the given width with all entries set to infinity.
+func createScheme(rows, k, width int) Scheme {
+	switch width {
+	case 1:
+		return createPackedScheme[uint8](rows, k, width)
+	case 2:
+		return createPackedScheme[uint16](rows, k, width)
+	case 4:
+		return createPackedScheme[uint32](rows, k, width)
+	}
+	return createPackedScheme[uint64](rows, k, width)
+}
+
+// Creates a scheme of the given width with all entries set to infinity
+// that only stores the finite entries if sparse.
+func createSchemeWithLayout(rows, k, width int, sparse bool) Scheme {
+	switch width {
+	case 1:
+		return createSchemeWithLayoutOf[uint8](rows, k, width, sparse)
+	case 2:
+		return createSchemeWithLayoutOf[uint16](rows, k, width, sparse)
+	case 4:
+		return createSchemeWithLayoutOf[uint32](rows, k, width, sparse)
+	}
+	return createSchemeWithLayoutOf[uint64](rows, k, width, sparse)
+}
+
+func createSchemeWithLayoutOf[T schemeEntry](rows, k, width int, sparse bool) Scheme {
+	if sparse {
+		return createSparseScheme[T](rows, k, width)
+	}
+	return createPackedScheme[T](rows, k, width)
+}
+
+func createPackedScheme[T schemeEntry](rows, k, width int) *packedScheme[T] {
+	data := make([]T, rows*k)
+	for i := range data {
+		data[i] = ^T(0)
+	}
+	return &packedScheme[T]{rows, k, width, k, data}
+}
+
+func (s *packedScheme[T]) Rows() int {
+	return s.rows
+}
+
+func (s *packedScheme[T]) Chains() int {
+	return s.k
+}
+
+func (s *packedScheme[T]) Width() int {
+	return s.width
+}
+
+func (s *packedScheme[T]) Entries() int {
+	return s.rows * s.k
+}
+
+func (s *packedScheme[T]) Sparse() bool {
+	return false
+}
+
+// Returns the entries of the row of v.
+func (s *packedScheme[T]) row(v int) []T {
+	return s.data[v*s.stride : v*s.stride+s.k]
+}
+
+func (s *packedScheme[T]) Get(v, c int) int {
+	entry := s.data[v*s.stride+c]
+	if entry == ^T(0) {
+		return math.MaxInt
+	}
+	return int(entry)
+}
+
+func (s *packedScheme[T]) eachEntry(v int, f func(c, pos int)) {
+	for c, entry := range s.row(v) {
+		if entry != ^T(0) {
+			f(c, int(entry))
+		}
+	}
+}
+
+func (s *packedScheme[T]) lower(v, c, pos int) {
+	if T(pos) < s.data[v*s.stride+c] {
+		s.data[v*s.stride+c] = T(pos)
+	}
+}
+
+func (s *packedScheme[T]) setRow(v int, row []int) {
+	entries := s.row(v)
+	for c, pos := range row {
+		if isInfinite(pos) {
+			entries[c] = ^T(0)
+		} else {
+			entries[c] = T(pos)
+		}
+	}
+}
+
+// Grows the stride by at least half when the chains do not fit, so that
+// adding chains one by one copies the rows amortized O(1) times.
+func (s *packedScheme[T]) grow(rows, chains int) {
+	if s.k+chains > s.stride {
+		stride := max(s.stride+s.stride/2, s.k+chains)
+		data := make([]T, s.rows*stride)
+		for i := range data {
+			data[i] = ^T(0)
+		}
+		for v := 0; v < s.rows; v++ {
+			copy(data[v*stride:], s.row(v))
+		}
+		s.data, s.stride = data, stride
+	}
+	s.k += chains
+	for i := 0; i < rows*s.stride; i++ {
+		s.data = append(s.data, ^T(0))
+	}
+	s.rows += rows
+}
+
+func (s *packedScheme[T]) mergeRow(v, w int) {
+	vRow := s.row(v)
+	wRow := s.row(w)
+	for j := range vRow {
+		vRow[j] = min(vRow[j], wRow[j])
+	}
+}
+
+func (s *packedScheme[T]) writeTo(w io.Writer) error {
+	buf := make([]byte, 0, s.k*s.width)
+	for v := 0; v < s.rows; v++ {
+		buf = buf[:0]
+		for _, entry := range s.row(v) {
+			buf = appendEntry(buf, uint64(entry), s.width)
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *packedScheme[T]) readFrom(r io.Reader) error {
+	buf := make([]byte, s.k*s.width)
+	for v := 0; v < s.rows; v++ {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return err
+		}
+		row := s.row(v)
+		for j := range row {
+			row[j] = T(entryAt(buf, j, s.width))
+		}
+	}
+	return nil
+}
+
+// Fills row with the entries of the row of v in s, math.MaxInt for unreached chains.
+func getRow(s Scheme, v int, row []int) []int {
+	row = row[:0]
+	for c := 0; c < s.Chains(); c++ {
+		row = append(row, math.MaxInt)
+	}
+	s.eachEntry(v, func(c, pos int) {
+		row[c] = pos
+	})
+	return row
+}
+
+// Returns a copy of s with the same layout and the given number of rows,
+// chains and entry width. Added rows and chains are unreached.
+func resizeScheme(s Scheme, rows, k, width int) Scheme {
+	switch width {
+	case 1:
+		return resizeSchemeOf[uint8](s, rows, k, width)
+	case 2:
+		return resizeSchemeOf[uint16](s, rows, k, width)
+	case 4:
+		return resizeSchemeOf[uint32](s, rows, k, width)
+	}
+	return resizeSchemeOf[uint64](s, rows, k, width)
+}
+
+func resizeSchemeOf[T schemeEntry](s Scheme, rows, k, width int) Scheme {
+	var resized Scheme
+	if s.Sparse() {
+		resized = createSparseScheme[T](rows, k, width)
+	} else {
+		resized = createPackedScheme[T](rows, k, width)
+	}
+	row := make([]int, k)
+	for v := 0; v < min(rows, s.Rows()); v++ {
+		for c := range row {
+			row[c] = math.MaxInt
+		}
+		s.eachEntry(v, func(c, pos int) {
+			if c < k {
+				row[c] = pos
+			}
+		})
+		resized.setRow(v, row)
+	}
+	return resized
+}
+
+// Reads a scheme written by Scheme.writeTo.
+// A sparse scheme holds the given number of entries.
+func readScheme(r io.Reader, rows, k, width int, sparse bool, entries int) (Scheme, error) {
+	switch width {
+	case 1:
+		return readSchemeOf[uint8](r, rows, k, width, sparse, entries)
+	case 2:
+		return readSchemeOf[uint16](r, rows, k, width, sparse, entries)
+	case 4:
+		return readSchemeOf[uint32](r, rows, k, width, sparse, entries)
+	}
+	return readSchemeOf[uint64](r, rows, k, width, sparse, entries)
+}
+
+func readSchemeOf[T schemeEntry](r io.Reader, rows, k, width int, sparse bool, entries int) (Scheme, error) {
+	if sparse {
+		s := createSparseScheme[T](rows, k, width)
+		return s, s.readFrom(r, entries)
+	}
+	s := createPackedScheme[T](rows, k, width)
+	return s, s.readFrom(r)
+}
+
+func appendEntry(buf []byte, entry uint64, width int) []byte {
+	switch width {
+	case 1:
+		return append(buf, uint8(entry))
+	case 2:
+		return binary.LittleEndian.AppendUint16(buf, uint16(entry))
+	case 4:
+		return binary.LittleEndian.AppendUint32(buf, uint32(entry))
+	}
+	return binary.LittleEndian.AppendUint64(buf, entry)
+}
+
+func entryAt(buf []byte, i, width int) uint64 {
+	switch width {
+	case 1:
+		return uint64(buf[i])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(buf[2*i:]))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return binary.LittleEndian.Uint64(buf[8*i:])
+}
@@ -0,0 +1,127 @@
+package fruit
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Loops with fewer iterations run on the calling goroutine
+const minParallel = 64
+
+// Returns the vertices of the DAG g grouped by their level, the length of the
+// longest path to a sink, in O(|V| + |E|). A vertex only has successors on
+// lower levels, so the rows of one level can be filled independently.
+// Each level lists its vertices from back to front in topo.
+func (g *CSRGraph) topoLevels(topo []int) [][]int {
+	level := make([]int, g.n)
+	var levels [][]int
+	for i := len(topo) - 1; i >= 0; i-- {
+		v := topo[i]
+		for _, w := range g.outs(v) {
+			level[v] = max(level[v], level[w]+1)
+		}
+		if level[v] == len(levels) {
+			levels = append(levels, nil)
+		}
+		levels[level[v]] = append(levels[level[v]], v)
+	}
+	return levels
+}
+
+// Calls f for every index below n on the given number of goroutines,
+// passing the number of the goroutine for per-goroutine buffers.
+func parallelFor(n, workers int, f func(worker, i int)) {
+	if workers <= 1 || n < minParallel {
+		for i := 0; i < n; i++ {
+			f(0, i)
+		}
+		return
+	}
+	const chunk = 16
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				start := int(next.Add(chunk)) - chunk
+				if start >= n {
+					return
+				}
+				for i := start; i < min(start+chunk, n); i++ {
+					f(worker, i)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// Row of a level collected by a worker, stored in its buffers
+type collectedRow struct {
+	worker     int
+	start, end int
+}
+
+// Creates the same scheme as createIndexingScheme, filling the rows of each
+// topological level on the given number of goroutines. Sparse rows are
+// collected in per-goroutine buffers and appended in level order.
+func createIndexingSchemeParallel[T schemeEntry](g *CSRGraph, topo []int, decomp *Decomposition, width, workers int) Scheme {
+	g.stats.SchemeNodes += uint(g.n)
+	levels := g.topoLevels(topo)
+	k := decomp.chains.n
+	sparse := createSparseScheme[T](g.n, k, width)
+	maxBytes := g.n * k * width / 2
+
+	scratch := make([][]int, workers)
+	touched := make([][]int, workers)
+	chains := make([][]uint32, workers)
+	positions := make([][]T, workers)
+	for w := range scratch {
+		scratch[w] = make([]int, k)
+		for c := range scratch[w] {
+			scratch[w][c] = math.MaxInt
+		}
+	}
+	l := 0
+	for ; l < len(levels) && sparse.bytes() <= maxBytes; l++ {
+		level := levels[l]
+		rows := make([]collectedRow, len(level))
+		for w := range chains {
+			chains[w], positions[w] = chains[w][:0], positions[w][:0]
+		}
+		parallelFor(len(level), workers, func(w, i int) {
+			touched[w] = sparse.collectRow(level[i], g, decomp, scratch[w], touched[w])
+			rows[i] = collectedRow{w, len(chains[w]), len(chains[w]) + len(touched[w])}
+			for _, c := range touched[w] {
+				chains[w] = append(chains[w], uint32(c))
+				positions[w] = append(positions[w], T(scratch[w][c]))
+				scratch[w][c] = math.MaxInt
+			}
+		})
+		for i, v := range level {
+			row := rows[i]
+			sparse.writeRow(v, chains[row.worker][row.start:row.end], positions[row.worker][row.start:row.end])
+			g.stats.SchemeNodes++
+			g.stats.SchemeEdges += uint(g.outDeg(v))
+		}
+	}
+	if l == len(levels) && sparse.bytes() <= maxBytes {
+		return sparse
+	}
+	// Too many finite entries, continue on a dense scheme
+	indexingScheme := sparse.toDense()
+	for ; l < len(levels); l++ {
+		level := levels[l]
+		parallelFor(len(level), workers, func(_, i int) {
+			fillDenseRow(indexingScheme, level[i], g, decomp)
+		})
+		for _, v := range level {
+			g.stats.SchemeNodes++
+			g.stats.SchemeEdges += uint(g.outDeg(v))
+		}
+	}
+	return indexingScheme
+}
@@ -0,0 +1,189 @@
+package fruit
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSchemeWidth(t *testing.T) {
+	cases := map[int]int{
+		1: 1, math.MaxUint8: 1, math.MaxUint8 + 1: 2,
+		math.MaxUint16: 2, math.MaxUint16 + 1: 4,
+		math.MaxUint32: 4, math.MaxUint32 + 1: 8,
+	}
+	for maxLen, width := range cases {
+		if got := schemeWidthFor(maxLen); got != width {
+			t.Errorf("schemeWidthFor(%d) = %d, want %d", maxLen, got, width)
+		}
+	}
+}
+
+func TestSchemeLayouts(t *testing.T) {
+	schemes := []Scheme{
+		createScheme(3, 2, 1), createScheme(3, 2, 2), createScheme(3, 2, 4), createScheme(3, 2, 8),
+		createSparseScheme[uint8](3, 2, 1), createSparseScheme[uint64](3, 2, 8),
+	}
+	for _, s := range schemes {
+		width, sparse := s.Width(), s.Sparse()
+		if !isInfinite(s.Get(0, 0)) {
+			t.Fatalf("Width %d, sparse %t: new scheme is not infinite", width, sparse)
+		}
+		s.lower(1, 0, 7)
+		s.lower(1, 0, 9)
+		s.lower(2, 1, 3)
+		s.mergeRow(0, 1)
+		s.mergeRow(0, 2)
+		if s.Get(0, 0) != 7 || s.Get(0, 1) != 3 || !isInfinite(s.Get(1, 1)) {
+			t.Fatalf("Width %d, sparse %t: unexpected entries after merging", width, sparse)
+		}
+		entries := 0
+		s.eachEntry(0, func(c, pos int) {
+			if c != entries || pos != s.Get(0, c) {
+				t.Fatalf("Width %d, sparse %t: entry (%d, %d) out of order", width, sparse, c, pos)
+			}
+			entries++
+		})
+
+		var buf bytes.Buffer
+		if err := s.writeTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		read, err := readScheme(&buf, 3, 2, width, sparse, s.Entries())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != 0 {
+			t.Fatalf("Width %d, sparse %t: %d bytes left after reading", width, sparse, buf.Len())
+		}
+		for v := 0; v < 3; v++ {
+			for c := 0; c < 2; c++ {
+				if read.Get(v, c) != s.Get(v, c) {
+					t.Fatalf("Width %d, sparse %t: entry (%d, %d) differs after reading", width, sparse, v, c)
+				}
+			}
+		}
+	}
+}
+
+// Checks that sparse and dense construction result in the same scheme.
+func TestSparseConstruction(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_1000_1000.gr",
+		"./data/gnm/gnm_1000_10000.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g, topo, decomp, scheme := readTestGraph(file, t).RunIndexingScheme(H3Concat)
+			sparse := createSparseScheme[uint32](g.n, decomp.chains.n, 4)
+			sparse.fill(g, topo, len(topo)-1, decomp, math.MaxInt)
+			dense := sparse.toDense()
+			for v := 0; v < g.n; v++ {
+				for c := 0; c < decomp.chains.n; c++ {
+					if sparse.Get(v, c) != scheme.Get(v, c) || dense.Get(v, c) != scheme.Get(v, c) {
+						t.Fatalf("Entry (%d, %d) differs between layouts", v, c)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Checks that repeated row updates keep the sparse arrays bounded
+// by the stored entries and leave the same entries as a dense scheme.
+func TestSparseUpdates(t *testing.T) {
+	const rows, k = 50, 20
+	sparse := createSparseScheme[uint16](rows, k, 2)
+	dense := createPackedScheme[uint16](rows, k, 2)
+	rng := rand.New(rand.NewSource(1))
+	row := make([]int, k)
+	for i := 0; i < 20000; i++ {
+		v := rng.Intn(rows)
+		switch rng.Intn(3) {
+		case 0:
+			for c := range row {
+				row[c] = math.MaxInt
+				if rng.Intn(3) == 0 {
+					row[c] = rng.Intn(100)
+				}
+			}
+			sparse.setRow(v, row)
+			dense.setRow(v, row)
+		case 1:
+			c, pos := rng.Intn(k), rng.Intn(100)
+			sparse.lower(v, c, pos)
+			dense.lower(v, c, pos)
+		default:
+			w := rng.Intn(rows)
+			sparse.mergeRow(v, w)
+			dense.mergeRow(v, w)
+		}
+		if live := sparse.Entries(); 2*len(sparse.chains) > 3*live+rows {
+			t.Fatalf("Sparse scheme stores %d entries for %d live ones after %d updates", len(sparse.chains), live, i+1)
+		}
+	}
+	for v := 0; v < rows; v++ {
+		for c := 0; c < k; c++ {
+			if sparse.Get(v, c) != dense.Get(v, c) {
+				t.Fatalf("Entry (%d, %d) differs between layouts", v, c)
+			}
+		}
+	}
+
+	// An empty row stored after the last one, as filling leaves it,
+	// stays valid when the last row shrinks
+	s := createSparseScheme[uint8](2, 3, 1)
+	s.setRow(0, []int{1, 2, 3})
+	s.start[1], s.end[1] = 3, 3
+	s.setRow(0, []int{1, math.MaxInt, math.MaxInt})
+	s.setRow(1, []int{math.MaxInt, math.MaxInt, math.MaxInt})
+	s.setRow(1, []int{4, 5, math.MaxInt})
+	if s.Get(0, 0) != 1 || !isInfinite(s.Get(0, 1)) || s.Get(1, 0) != 4 || s.Get(1, 1) != 5 || !isInfinite(s.Get(1, 2)) {
+		t.Error("Rows changed after rewriting the row before an empty one")
+	}
+}
+
+// Checks that filling the rows on several goroutines results in the same scheme.
+func TestParallelConstruction(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_1000_1000.gr",
+		"./data/gnm/gnm_1000_10000.gr",
+		"./data/gn/gn_100.gr",
+		"./data/real_world/Wiki-Vote.gr",
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+			g, topo, decomp, _ := readTestGraph(file, t).RunIndexingScheme(H3Concat)
+			g.stats.SchemeNodes, g.stats.SchemeEdges = 0, 0
+			scheme := g.CreateIndexingScheme(topo, decomp)
+			stats := *g.stats
+			g.SetBuildOptions(BuildOptions{Workers: 4})
+			g.stats.SchemeNodes, g.stats.SchemeEdges = 0, 0
+			parallel := g.CreateIndexingScheme(topo, decomp)
+
+			if parallel.Sparse() != scheme.Sparse() || parallel.Width() != scheme.Width() || parallel.Entries() != scheme.Entries() {
+				t.Fatalf("Parallel scheme has layout (%t, %d, %d), want (%t, %d, %d)",
+					parallel.Sparse(), parallel.Width(), parallel.Entries(), scheme.Sparse(), scheme.Width(), scheme.Entries())
+			}
+			if g.stats.SchemeNodes != stats.SchemeNodes || g.stats.SchemeEdges != stats.SchemeEdges {
+				t.Errorf("Parallel construction counted %d nodes and %d edges, want %d and %d",
+					g.stats.SchemeNodes, g.stats.SchemeEdges, stats.SchemeNodes, stats.SchemeEdges)
+			}
+			for v := 0; v < g.n; v++ {
+				for c := 0; c < decomp.chains.n; c++ {
+					if parallel.Get(v, c) != scheme.Get(v, c) {
+						t.Fatalf("Entry (%d, %d) differs between sequential and parallel construction", v, c)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+import json
+import subprocess
+import threading
+
+
+class FruitCoprocess:
+    """Runs fruit in coprocess mode and sends it JSON requests.
+
+    Example:
+        with FruitCoprocess('data/gnm/gnm_100_100.gr') as fruit:
+            fruit.request('reach', source=1, target=2)
+    """
+
+    def __init__(self, file_path, options='', binary='./fruit'):
+        self.process = subprocess.Popen([binary, 'coproc'] + options.split() + [file_path],
+                                        stdin=subprocess.PIPE, stdout=subprocess.PIPE, text=True)
+        self.next_id = 0
+
+    def request_many(self, requests):
+        """Sends the (op, arguments) requests from a second thread while reading
+        the responses, so that neither pipe fills up and blocks both processes.
+        Returns the results in the order of the requests."""
+        ids = list(range(self.next_id, self.next_id + len(requests)))
+        self.next_id += len(requests)
+        write_errors = []
+
+        def write():
+            try:
+                for request_id, (op, arguments) in zip(ids, requests):
+                    self.process.stdin.write(json.dumps({'id': request_id, 'op': op, **arguments}) + '\n')
+                self.process.stdin.flush()
+            except OSError as error:
+                write_errors.append(error)
+
+        writer = threading.Thread(target=write, daemon=True)
+        writer.start()
+        responses = {}
+        try:
+            while len(responses) < len(ids):
+                line = self.process.stdout.readline()
+                if not line:
+                    raise RuntimeError('fruit coprocess exited')
+                response = json.loads(line)
+                responses[response['id']] = response
+        finally:
+            writer.join()
+        if write_errors:
+            raise RuntimeError('writing to the fruit coprocess failed') from write_errors[0]
+        results = []
+        for request_id in ids:
+            if 'error' in responses[request_id]:
+                raise RuntimeError(responses[request_id]['error'])
+            results.append(responses[request_id]['result'])
+        return results
+
+    def request(self, op, **arguments):
+        return self.request_many([(op, arguments)])[0]
+
+    def close(self):
+        self.process.stdin.close()
+        self.process.wait()
+
+    def __enter__(self):
+        return self
+
+    def __exit__(self, *args):
+        self.close()
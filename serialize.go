@@ -0,0 +1,336 @@
+package fruit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+)
+
+// Layout of an index file (all values little-endian):
+//
+//	header  magic "FRUITIDX", version u32, width u16, layout u16,
+//	        n, m, comps, chains, ids, entries u64, body-crc u32, header-crc u32
+//	body    vToComp [n]u64, vToId [n]i64, idToV [ids](id i64, v u64) sorted by id,
+//	        chainOf [comps]u64, posOf [comps]u64, scheme
+//
+// A dense scheme (layout 0) is stored as [comps*chains]u<width*8>, where the
+// maximum value marks unreachable chains. A sparse scheme (layout 1) with the
+// given number of finite entries is stored as row offsets [comps+1]u64,
+// chains [entries]u32 padded to 8 bytes and positions [entries]u<width*8>.
+// All sections start 8 byte aligned, so they can be used in place when the
+// file is mapped into memory. Vertices without an ID are stored as the minimum i64.
+const (
+	indexMagic      = "FRUITIDX"
+	indexVersion    = 4
+	indexHeaderSize = 72
+	noID            = math.MinInt64
+)
+
+var (
+	ErrIndexFormat   = errors.New("not a fruit index file")
+	ErrIndexVersion  = errors.New("unsupported index file version")
+	ErrIndexChecksum = errors.New("index file checksum mismatch")
+	ErrIndexCorrupt  = errors.New("index file is corrupt")
+)
+
+type indexHeader struct {
+	version uint32
+	width   uint16
+	sparse  bool
+	n       uint64
+	m       uint64
+	comps   uint64
+	chains  uint64
+	ids     uint64
+	entries uint64
+	bodyCRC uint32
+}
+
+func (h *indexHeader) encode() []byte {
+	b := make([]byte, indexHeaderSize)
+	copy(b, indexMagic)
+	binary.LittleEndian.PutUint32(b[8:], h.version)
+	binary.LittleEndian.PutUint16(b[12:], h.width)
+	if h.sparse {
+		binary.LittleEndian.PutUint16(b[14:], 1)
+	}
+	binary.LittleEndian.PutUint64(b[16:], h.n)
+	binary.LittleEndian.PutUint64(b[24:], h.m)
+	binary.LittleEndian.PutUint64(b[32:], h.comps)
+	binary.LittleEndian.PutUint64(b[40:], h.chains)
+	binary.LittleEndian.PutUint64(b[48:], h.ids)
+	binary.LittleEndian.PutUint64(b[56:], h.entries)
+	binary.LittleEndian.PutUint32(b[64:], h.bodyCRC)
+	binary.LittleEndian.PutUint32(b[68:], crc32.ChecksumIEEE(b[:68]))
+	return b
+}
+
+func decodeIndexHeader(b []byte) (indexHeader, error) {
+	var h indexHeader
+	if len(b) < indexHeaderSize || string(b[:8]) != indexMagic {
+		return h, ErrIndexFormat
+	}
+	if binary.LittleEndian.Uint32(b[68:]) != crc32.ChecksumIEEE(b[:68]) {
+		return h, fmt.Errorf("%w in header", ErrIndexChecksum)
+	}
+	h.version = binary.LittleEndian.Uint32(b[8:])
+	if h.version != indexVersion {
+		return h, fmt.Errorf("%w: %d", ErrIndexVersion, h.version)
+	}
+	h.width = binary.LittleEndian.Uint16(b[12:])
+	if h.width != 1 && h.width != 2 && h.width != 4 && h.width != 8 {
+		return h, fmt.Errorf("%w: scheme width %d", ErrIndexCorrupt, h.width)
+	}
+	layout := binary.LittleEndian.Uint16(b[14:])
+	if layout > 1 {
+		return h, fmt.Errorf("%w: scheme layout %d", ErrIndexCorrupt, layout)
+	}
+	h.sparse = layout == 1
+	h.n = binary.LittleEndian.Uint64(b[16:])
+	h.m = binary.LittleEndian.Uint64(b[24:])
+	h.comps = binary.LittleEndian.Uint64(b[32:])
+	h.chains = binary.LittleEndian.Uint64(b[40:])
+	h.ids = binary.LittleEndian.Uint64(b[48:])
+	h.entries = binary.LittleEndian.Uint64(b[56:])
+	h.bodyCRC = binary.LittleEndian.Uint32(b[64:])
+	return h, nil
+}
+
+// Returns the number of bytes of the body described by h
+// or false if the counts are too large to describe a valid index.
+func (h *indexHeader) bodySize() (int64, bool) {
+	const maxCount = 1 << 40
+	if h.n > maxCount || h.ids > maxCount || h.comps > maxCount || h.chains > maxCount {
+		return 0, false
+	}
+	hi, entries := bits.Mul64(h.comps, h.chains)
+	if hi != 0 || entries > math.MaxInt64/16 || (h.sparse && h.entries > entries) {
+		return 0, false
+	}
+	words := 2 * (h.n + h.ids + h.comps)
+	if h.sparse {
+		size := sparseSchemeSize(int(h.comps), int(h.entries), int(h.width))
+		return int64(words*8) + int64(size), true
+	}
+	return int64(words*8 + entries*uint64(h.width)), true
+}
+
+// Writes 8 byte words. Errors stick to the underlying bufio.Writer
+// and are reported by Flush.
+type wordWriter struct {
+	w   *bufio.Writer
+	buf [8]byte
+}
+
+func (ww *wordWriter) put(v uint64) {
+	binary.LittleEndian.PutUint64(ww.buf[:], v)
+	ww.w.Write(ww.buf[:])
+}
+
+// Reads 8 byte words and remembers the first error.
+type wordReader struct {
+	r   *bufio.Reader
+	buf [8]byte
+	err error
+}
+
+func (wr *wordReader) get() uint64 {
+	if wr.err != nil {
+		return 0
+	}
+	if _, err := io.ReadFull(wr.r, wr.buf[:]); err != nil {
+		wr.err = err
+		return 0
+	}
+	return binary.LittleEndian.Uint64(wr.buf[:])
+}
+
+// Returns the ID of every vertex or noID for vertices without an ID.
+func (idx *Index) vertexIDs() []int64 {
+	ids := make([]int64, idx.n)
+	for v := range ids {
+		ids[v] = noID
+		if id, ok := idx.ID(v); ok {
+			ids[v] = int64(id)
+		}
+	}
+	return ids
+}
+
+// Writes the index to the file at path so that it can be restored with Load.
+// Free positions left before chains by AppendVertex are dropped first.
+func (idx *Index) Save(path string) (err error) {
+	idx.normalize()
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	// Reserve the header, it is written once the body checksum is known
+	if _, err := file.Write(make([]byte, indexHeaderSize)); err != nil {
+		return fmt.Errorf("writing header of %s: %w", path, err)
+	}
+	crc := crc32.NewIEEE()
+	ww := &wordWriter{w: bufio.NewWriter(io.MultiWriter(file, crc))}
+
+	for _, c := range idx.vToComp {
+		ww.put(uint64(c))
+	}
+	vToId := idx.vertexIDs()
+	idToV := make([]int, 0, len(vToId))
+	for v, id := range vToId {
+		ww.put(uint64(id))
+		if id != noID {
+			idToV = append(idToV, v)
+		}
+	}
+	sort.Slice(idToV, func(i, j int) bool {
+		return vToId[idToV[i]] < vToId[idToV[j]]
+	})
+	for _, v := range idToV {
+		ww.put(uint64(vToId[v]))
+		ww.put(uint64(v))
+	}
+	for c := range idx.chainOf {
+		ww.put(uint64(idx.chainOf[c]))
+	}
+	for c := range idx.posOf {
+		ww.put(uint64(idx.posOf[c]))
+	}
+	if err := idx.scheme.writeTo(ww.w); err != nil {
+		return fmt.Errorf("writing scheme of %s: %w", path, err)
+	}
+	if err := ww.w.Flush(); err != nil {
+		return fmt.Errorf("writing body of %s: %w", path, err)
+	}
+
+	h := indexHeader{
+		indexVersion, uint16(idx.scheme.Width()), idx.scheme.Sparse(),
+		uint64(idx.n), uint64(idx.m), uint64(len(idx.chainOf)),
+		uint64(idx.scheme.Chains()), uint64(len(idToV)), 0, crc.Sum32(),
+	}
+	if h.sparse {
+		h.entries = uint64(idx.scheme.Entries())
+	}
+	if _, err := file.WriteAt(h.encode(), 0); err != nil {
+		return fmt.Errorf("writing header of %s: %w", path, err)
+	}
+	return nil
+}
+
+// Restores an index written by Index.Save.
+// The original graph is not needed to answer queries on the loaded index.
+func Load(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hBytes := make([]byte, indexHeaderSize)
+	if _, err := io.ReadFull(file, hBytes); err != nil {
+		return nil, fmt.Errorf("reading header of %s: %w", path, ErrIndexFormat)
+	}
+	h, err := decodeIndexHeader(hBytes)
+	if err != nil {
+		return nil, fmt.Errorf("reading header of %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size, ok := h.bodySize()
+	if !ok || info.Size()-indexHeaderSize != size {
+		return nil, fmt.Errorf("%s: %w: size does not match header", path, ErrIndexCorrupt)
+	}
+
+	crc := crc32.NewIEEE()
+	wr := &wordReader{r: bufio.NewReader(io.TeeReader(file, crc))}
+	n, comps, chains := int(h.n), int(h.comps), int(h.chains)
+	idx := &Index{
+		n:       n,
+		m:       int(h.m),
+		vToComp: make([]int, n),
+		chainOf: make([]int, comps),
+		posOf:   make([]int, comps),
+		idMapping: IdMapping{
+			make(map[int]int, h.ids), make(map[int]int, h.ids),
+		},
+		stats: BuildStats{
+			Nodes: n, Edges: int(h.m), SCCs: comps, Chains: chains,
+			SchemeWidth: int(h.width), SchemeSparse: h.sparse,
+		},
+	}
+	valid := true
+	for v := range idx.vToComp {
+		c := wr.get()
+		valid = valid && c < h.comps
+		idx.vToComp[v] = int(c)
+	}
+	for v := 0; v < n; v++ {
+		if id := int64(wr.get()); id != noID {
+			idx.idMapping.vToId[v] = int(id)
+			idx.idMapping.idToV[int(id)] = v
+		}
+	}
+	// The sorted ID table serves lookups without a map, the maps are rebuilt instead
+	for i := uint64(0); i < 2*h.ids; i++ {
+		wr.get()
+	}
+	for c := range idx.chainOf {
+		idx.chainOf[c] = int(wr.get())
+	}
+	for c := range idx.posOf {
+		idx.posOf[c] = int(wr.get())
+	}
+	if wr.err == nil {
+		idx.scheme, wr.err = readScheme(wr.r, comps, chains, int(h.width), h.sparse, int(h.entries))
+	}
+	if wr.err != nil {
+		return nil, fmt.Errorf("reading body of %s: %w", path, wr.err)
+	}
+	idx.stats.SchemeEntries = idx.scheme.Entries()
+	if crc.Sum32() != h.bodyCRC {
+		return nil, fmt.Errorf("%s: %w", path, ErrIndexChecksum)
+	}
+	if !valid || !validChainPositions(idx.chainOf, idx.posOf, chains) || len(idx.idMapping.vToId) != int(h.ids) {
+		return nil, fmt.Errorf("%s: %w", path, ErrIndexCorrupt)
+	}
+	return idx, nil
+}
+
+// Returns whether the components of every chain occupy each of the
+// positions 0..length-1 of the chain exactly once in O(comps + chains).
+func validChainPositions(chainOf, posOf []int, chains int) bool {
+	start := make([]int, chains+1)
+	for _, chain := range chainOf {
+		if chain < 0 || chain >= chains {
+			return false
+		}
+		start[chain+1]++
+	}
+	for chain := 0; chain < chains; chain++ {
+		start[chain+1] += start[chain]
+	}
+	taken := make([]bool, len(chainOf))
+	for c, chain := range chainOf {
+		pos := posOf[c]
+		if pos < 0 || pos >= start[chain+1]-start[chain] || taken[start[chain]+pos] {
+			return false
+		}
+		taken[start[chain]+pos] = true
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package fruit
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			idx := createTestIndex(readTestGraph(file, t), t)
+			path := filepath.Join(t.TempDir(), "index.fruit")
+			if err := idx.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.src != nil || loaded.N() != idx.N() {
+				t.Fatalf("Loaded index has %d vertices, want %d", loaded.N(), idx.N())
+			}
+			if !compQuadraticMatrices(idx.Matrix(), loaded.Matrix()) {
+				t.Error("Loaded index answers differently than the built one")
+			}
+			for v, id := range idx.idMapping.vToId {
+				if loaded.idMapping.vToId[v] != id || loaded.idMapping.idToV[id] != v {
+					t.Fatalf("ID mapping of vertex %d was not restored", v)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	idx := createTestIndex(readTestGraph("./test_graphs/collapse.gr", t), t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.fruit")
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt := func(name string, change func([]byte) []byte, want error) {
+		t.Run(name, func(t *testing.T) {
+			b := change(append([]byte(nil), data...))
+			p := filepath.Join(dir, name)
+			if err := os.WriteFile(p, b, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(p); !errors.Is(err, want) {
+				t.Fatalf("Expected %v, got %v", want, err)
+			}
+		})
+	}
+	corrupt("magic", func(b []byte) []byte { b[0] = 'X'; return b }, ErrIndexFormat)
+	corrupt("header", func(b []byte) []byte { b[16]++; return b }, ErrIndexChecksum)
+	corrupt("body", func(b []byte) []byte { b[len(b)-1] ^= 1; return b }, ErrIndexChecksum)
+	corrupt("truncated", func(b []byte) []byte { return b[:len(b)-8] }, ErrIndexCorrupt)
+	corrupt("empty", func(b []byte) []byte { return nil }, ErrIndexFormat)
+}
+
+func TestOpenMapped(t *testing.T) {
+	// Dense and sparse scheme
+	files := []string{
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			testOpenMapped(file, t)
+		})
+	}
+}
+
+func testOpenMapped(file string, t *testing.T) {
+	idx := createTestIndex(readTestGraph(file, t), t)
+	path := filepath.Join(t.TempDir(), "index.fruit")
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+
+	if !compQuadraticMatrices(idx.Matrix(), mapped.Matrix()) {
+		t.Error("Mapped index answers differently than the built one")
+	}
+	for v, id := range idx.idMapping.vToId {
+		if w, ok := mapped.Vertex(id); !ok || w != v {
+			t.Fatalf("ID %d maps to %d, want %d", id, w, v)
+		}
+		if got, ok := mapped.ID(v); !ok || got != id {
+			t.Fatalf("Vertex %d has ID %d, want %d", v, got, id)
+		}
+	}
+	if _, ok := mapped.Vertex(-1); ok {
+		t.Error("Found vertex for unknown ID")
+	}
+	// A mapped index can be saved again
+	copyPath := filepath.Join(t.TempDir(), "copy.fruit")
+	if err := mapped.Save(copyPath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(copyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compQuadraticMatrices(idx.Matrix(), loaded.Matrix()) {
+		t.Error("Index saved from the mapping answers differently")
+	}
+}
+
+func TestLoadDuplicatePositions(t *testing.T) {
+	idx := createTestIndex(readTestGraph("./data/gnm/gnm_100_100.gr", t), t)
+	path := filepath.Join(t.TempDir(), "index.fruit")
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := decodeIndexHeader(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Move a component to the position of another one on its chain
+	// and store checksums that match the changed body
+	chainOff := indexHeaderSize + 8*int(2*h.n+2*h.ids)
+	posOff := chainOff + 8*int(h.comps)
+	word := func(off, c int) []byte { return b[off+8*c : off+8*c+8] }
+	moved := -1
+	for c := 1; c < int(h.comps) && moved == -1; c++ {
+		if binary.LittleEndian.Uint64(word(chainOff, c)) == binary.LittleEndian.Uint64(word(chainOff, 0)) {
+			moved = c
+		}
+	}
+	if moved == -1 {
+		t.Fatal("No chain holds two components")
+	}
+	copy(word(posOff, moved), word(posOff, 0))
+	h.bodyCRC = crc32.ChecksumIEEE(b[indexHeaderSize:])
+	copy(b, h.encode())
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); !errors.Is(err, ErrIndexCorrupt) {
+		t.Errorf("Load returned %v, want %v", err, ErrIndexCorrupt)
+	}
+	if _, err := OpenMapped(path); !errors.Is(err, ErrIndexCorrupt) {
+		t.Errorf("OpenMapped returned %v, want %v", err, ErrIndexCorrupt)
+	}
+}
@@ -0,0 +1,206 @@
+package fruit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Reachability of one pair of vertex IDs, used by the server and the coprocess protocol.
+type PairResult struct {
+	Source    int  `json:"source"`
+	Target    int  `json:"target"`
+	Reachable bool `json:"reachable"`
+}
+
+// Index metadata served by /meta.
+type IndexMeta struct {
+	Vertices      int  `json:"vertices"`
+	Edges         int  `json:"edges"`
+	SCCs          int  `json:"sccs"`
+	Chains        int  `json:"chains"`
+	SchemeWidth   int  `json:"scheme_width"`
+	SchemeSparse  bool `json:"scheme_sparse"`
+	SchemeEntries int  `json:"scheme_entries"`
+	HasGraph      bool `json:"has_graph"`
+}
+
+// Returns the metadata of the index.
+func (idx *Index) Meta() IndexMeta {
+	return IndexMeta{
+		idx.stats.Nodes, idx.stats.Edges, idx.stats.SCCs, idx.stats.Chains,
+		idx.stats.SchemeWidth, idx.stats.SchemeSparse, idx.stats.SchemeEntries, idx.src != nil,
+	}
+}
+
+// Creates an HTTP handler answering queries on idx with JSON:
+//
+//	GET  /reach?s=<id>&t=<id>     {"source", "target", "reachable"}
+//	POST /reach                   {"pairs": [{"source", "target"}, ...]} -> {"results": [...]}
+//	GET  /path?s=<id>&t=<id>      {"source", "target", "path"}, path is null if there is none
+//	GET  /descendants?v=<id>      {"vertex", "descendants"}
+//	GET  /meta                    IndexMeta
+//
+// Errors are answered with {"error"} and status 400 for malformed requests,
+// 404 for unknown vertices and 501 for paths on an index without its graph.
+// The handler may serve requests concurrently.
+func CreateHandler(idx *Index) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reach", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s, t, err := pairParams(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			reachable, err := idx.Reachable(s, t)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, PairResult{s, t, reachable})
+		case http.MethodPost:
+			var request struct {
+				Pairs []PairResult `json:"pairs"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				writeError(w, &requestError{err.Error()})
+				return
+			}
+			results, err := idx.reachablePairs(request.Pairs)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, struct {
+				Results []PairResult `json:"results"`
+			}{results})
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, errMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/path", getOnly(func(w http.ResponseWriter, r *http.Request) {
+		s, t, err := pairParams(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		path, err := idx.Path(s, t)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, struct {
+			Source int   `json:"source"`
+			Target int   `json:"target"`
+			Path   []int `json:"path"`
+		}{s, t, path})
+	}))
+	mux.HandleFunc("/descendants", getOnly(func(w http.ResponseWriter, r *http.Request) {
+		v, err := idParam(r, "v")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		descendants := []int{}
+		err = idx.Descendants(v, func(w int) bool {
+			descendants = append(descendants, w)
+			return true
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, struct {
+			Vertex      int   `json:"vertex"`
+			Descendants []int `json:"descendants"`
+		}{v, descendants})
+	}))
+	mux.HandleFunc("/meta", getOnly(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, idx.Meta())
+	}))
+	return mux
+}
+
+// Answers the reachability of the given pairs of IDs.
+func (idx *Index) reachablePairs(pairs []PairResult) ([]PairResult, error) {
+	results := make([]PairResult, len(pairs))
+	for i, pair := range pairs {
+		reachable, err := idx.Reachable(pair.Source, pair.Target)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = PairResult{pair.Source, pair.Target, reachable}
+	}
+	return results, nil
+}
+
+var errMethodNotAllowed = errors.New("method not allowed")
+
+// requestError reports a malformed request.
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// Returns the handler that rejects requests other than GET.
+func getOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// Returns the vertex ID in the query parameter with the given name.
+func idParam(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &requestError{fmt.Sprintf("invalid vertex ID %q in parameter %s", value, name)}
+	}
+	return id, nil
+}
+
+// Returns the vertex IDs in the query parameters s and t.
+func pairParams(r *http.Request) (int, int, error) {
+	s, err := idParam(r, "s")
+	if err != nil {
+		return 0, 0, err
+	}
+	t, err := idParam(r, "t")
+	return s, t, err
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var unknownErr *UnknownVertexError
+	status := http.StatusBadRequest
+	switch {
+	case errors.As(err, &unknownErr):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoGraph):
+		status = http.StatusNotImplemented
+	case errors.Is(err, errMethodNotAllowed):
+		status = http.StatusMethodNotAllowed
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
@@ -0,0 +1,81 @@
+package fruit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Sends a request to the handler and decodes the JSON answer into v.
+// Returns the status code.
+func serveTestRequest(t *testing.T, handler http.Handler, method, url, body string, v any) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, url, strings.NewReader(body)))
+	if err := json.NewDecoder(recorder.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: decoding answer: %v", method, url, err)
+	}
+	return recorder.Code
+}
+
+func TestHandler(t *testing.T) {
+	g := readTestGraph("./test_graphs/collapse.gr", t)
+	handler := CreateHandler(createTestIndex(g, t))
+
+	var pair PairResult
+	if code := serveTestRequest(t, handler, "GET", "/reach?s=2&t=14", "", &pair); code != http.StatusOK || !pair.Reachable {
+		t.Errorf("GET /reach?s=2&t=14 = %d, %+v", code, pair)
+	}
+
+	var batch struct {
+		Results []PairResult `json:"results"`
+	}
+	body := `{"pairs": [{"source": 2, "target": 14}, {"source": 14, "target": 2}]}`
+	code := serveTestRequest(t, handler, "POST", "/reach", body, &batch)
+	want := []PairResult{{2, 14, true}, {14, 2, false}}
+	if code != http.StatusOK || !slices.Equal(batch.Results, want) {
+		t.Errorf("POST /reach = %d, %+v, want %+v", code, batch.Results, want)
+	}
+
+	var path struct {
+		Path []int `json:"path"`
+	}
+	if code := serveTestRequest(t, handler, "GET", "/path?s=2&t=14", "", &path); code != http.StatusOK ||
+		len(path.Path) == 0 || path.Path[0] != 2 || path.Path[len(path.Path)-1] != 14 {
+		t.Errorf("GET /path?s=2&t=14 = %d, %v", code, path.Path)
+	}
+
+	var desc struct {
+		Descendants []int `json:"descendants"`
+	}
+	if code := serveTestRequest(t, handler, "GET", "/descendants?v=9", "", &desc); code != http.StatusOK || len(desc.Descendants) != 0 {
+		t.Errorf("GET /descendants?v=9 = %d, %v", code, desc.Descendants)
+	}
+
+	var meta IndexMeta
+	if code := serveTestRequest(t, handler, "GET", "/meta", "", &meta); code != http.StatusOK || meta.Vertices != g.n || !meta.HasGraph {
+		t.Errorf("GET /meta = %d, %+v", code, meta)
+	}
+
+	failures := []struct {
+		method, url, body string
+		code              int
+	}{
+		{"GET", "/reach?s=2&t=99", "", http.StatusNotFound},
+		{"GET", "/reach?s=2", "", http.StatusBadRequest},
+		{"POST", "/reach", "{", http.StatusBadRequest},
+		{"DELETE", "/reach", "", http.StatusMethodNotAllowed},
+		{"POST", "/meta", "", http.StatusMethodNotAllowed},
+	}
+	for _, e := range failures {
+		var answer struct {
+			Error string `json:"error"`
+		}
+		if code := serveTestRequest(t, handler, e.method, e.url, e.body, &answer); code != e.code || answer.Error == "" {
+			t.Errorf("%s %s = %d, %q, want status %d", e.method, e.url, code, answer.Error, e.code)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package fruit
+
+import (
+	"math"
+)
+
+// Returns the distinct components of the given vertices.
+func (idx *Index) compsOf(vertices []int) []int {
+	seen := make(map[int]bool, len(vertices))
+	comps := make([]int, 0, len(vertices))
+	for _, v := range vertices {
+		if c := idx.vToComp[v]; !seen[c] {
+			seen[c] = true
+			comps = append(comps, c)
+		}
+	}
+	return comps
+}
+
+// Returns whether any vertex with an ID in sources can reach any vertex with
+// an ID in targets in O(|sources| * k + |targets|). The rows of the sources are
+// merged into their minimum per chain, which reaches everything any of them reaches.
+func (idx *Index) ReachableAny(sources, targets []int) (bool, error) {
+	return idx.setQuery(sources, targets, idx.reachableAny)
+}
+
+// Returns whether every vertex with an ID in sources can reach every vertex
+// with an ID in targets in O(|sources| * k + |targets|). Keeps the highest
+// entry per chain over the sources and the highest entry of another component,
+// which is used for targets in the component of the highest one.
+func (idx *Index) ReachableAll(sources, targets []int) (bool, error) {
+	return idx.setQuery(sources, targets, idx.reachableAll)
+}
+
+// Answers a set query on the vertices with the given IDs.
+func (idx *Index) setQuery(sourceIDs, targetIDs []int, query func(sources, targets []int) bool) (bool, error) {
+	sources, err := idx.vertices(sourceIDs)
+	if err != nil {
+		return false, err
+	}
+	targets, err := idx.vertices(targetIDs)
+	if err != nil {
+		return false, err
+	}
+	return query(sources, targets), nil
+}
+
+func (idx *Index) reachableAny(sources, targets []int) bool {
+	comps := idx.compsOf(sources)
+	inSources := make(map[int]bool, len(comps))
+	lowest := make([]int, idx.scheme.Chains())
+	for chain := range lowest {
+		lowest[chain] = math.MaxInt
+	}
+	for _, c := range comps {
+		inSources[c] = true
+		idx.scheme.eachEntry(c, func(chain, pos int) {
+			lowest[chain] = min(lowest[chain], pos)
+		})
+	}
+	for _, t := range targets {
+		c := idx.vToComp[t]
+		if inSources[c] || lowest[idx.chainOf[c]] <= idx.posOf[c] {
+			return true
+		}
+	}
+	return false
+}
+
+func (idx *Index) reachableAll(sources, targets []int) bool {
+	k := idx.scheme.Chains()
+	highest := make([]int, k)
+	highestComp := make([]int, k)
+	second := make([]int, k)
+	for chain := range highest {
+		highest[chain] = -1
+		highestComp[chain] = -1
+		second[chain] = -1
+	}
+	row := make([]int, k)
+	for chain := range row {
+		row[chain] = math.MaxInt
+	}
+	for _, c := range idx.compsOf(sources) {
+		idx.scheme.eachEntry(c, func(chain, pos int) {
+			row[chain] = pos
+		})
+		for chain, pos := range row {
+			if pos > highest[chain] {
+				second[chain] = highest[chain]
+				highest[chain] = pos
+				highestComp[chain] = c
+			} else if pos > second[chain] {
+				second[chain] = pos
+			}
+			row[chain] = math.MaxInt
+		}
+	}
+	for _, t := range targets {
+		c := idx.vToComp[t]
+		chain := idx.chainOf[c]
+		pos := highest[chain]
+		if highestComp[chain] == c {
+			pos = second[chain]
+		}
+		if pos > idx.posOf[c] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package fruit
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSetReachability(t *testing.T) {
+	files := []string{
+		"./test_graphs/collapse.gr",
+		"./data/gnm/gnm_100_100.gr",
+		"./data/gnm/gnm_100_1000.gr",
+		"./data/gn/gn_100.gr",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g := readTestGraph(file, t)
+			m := g.dfsCreateMatrix()
+			idx := createTestIndex(g, t)
+			random := rand.New(rand.NewSource(1))
+			randomIDs := func() []int {
+				ids := make([]int, random.Intn(4))
+				for j := range ids {
+					ids[j] = g.idMapping.vToId[random.Intn(len(g.idMapping.vToId))]
+				}
+				return ids
+			}
+
+			for i := 0; i < 1000; i++ {
+				sources, targets := randomIDs(), randomIDs()
+				anyPair, allPairs := false, true
+				for _, s := range sources {
+					for _, w := range targets {
+						reachable := m[g.idMapping.idToV[s]][g.idMapping.idToV[w]]
+						anyPair = anyPair || reachable
+						allPairs = allPairs && reachable
+					}
+				}
+				if got, err := idx.ReachableAny(sources, targets); err != nil || got != anyPair {
+					t.Fatalf("ReachableAny(%v, %v) = %t, %v, want %t", sources, targets, got, err, anyPair)
+				}
+				if got, err := idx.ReachableAll(sources, targets); err != nil || got != allPairs {
+					t.Fatalf("ReachableAll(%v, %v) = %t, %v, want %t", sources, targets, got, err, allPairs)
+				}
+			}
+			if _, err := idx.ReachableAny([]int{-1}, nil); err == nil {
+				t.Errorf("ReachableAny with unknown ID returned no error")
+			}
+		})
+	}
+}
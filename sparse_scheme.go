@@ -0,0 +1,345 @@
+package fruit
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Scheme keeping only the finite entries of every row as (chain, position)
+// pairs sorted by chain. Rows are appended in the order they are computed,
+// so row v is stored in chains[start[v]:end[v]] and pos[start[v]:end[v]].
+// Updated rows are rewritten in place if they fit, otherwise appended.
+// The arrays are compacted once the unused entries exceed half of the
+// rows and their entries together.
+type sparseScheme[T schemeEntry] struct {
+	rows   int
+	k      int
+	width  int
+	start  []int
+	end    []int
+	chains []uint32
+	pos    []T
+	dead   int // entries of the arrays outside of all rows
+}
+
+func createSparseScheme[T schemeEntry](rows, k, width int) *sparseScheme[T] {
+	return &sparseScheme[T]{
+		rows, k, width, make([]int, rows), make([]int, rows), nil, nil, 0,
+	}
+}
+
+func (s *sparseScheme[T]) Rows() int {
+	return s.rows
+}
+
+func (s *sparseScheme[T]) Chains() int {
+	return s.k
+}
+
+func (s *sparseScheme[T]) Width() int {
+	return s.width
+}
+
+func (s *sparseScheme[T]) Entries() int {
+	entries := 0
+	for v := 0; v < s.rows; v++ {
+		entries += s.end[v] - s.start[v]
+	}
+	return entries
+}
+
+func (s *sparseScheme[T]) Sparse() bool {
+	return true
+}
+
+// Returns the number of bytes used by the stored rows.
+func (s *sparseScheme[T]) bytes() int {
+	return len(s.chains)*(4+s.width) + s.rows*bits.UintSize/4
+}
+
+// Binary searches chain c in the row of v in O(log k).
+func (s *sparseScheme[T]) Get(v, c int) int {
+	chains := s.chains[s.start[v]:s.end[v]]
+	i := sort.Search(len(chains), func(i int) bool {
+		return int(chains[i]) >= c
+	})
+	if i < len(chains) && int(chains[i]) == c {
+		return int(s.pos[s.start[v]+i])
+	}
+	return math.MaxInt
+}
+
+func (s *sparseScheme[T]) eachEntry(v int, f func(c, pos int)) {
+	for i := s.start[v]; i < s.end[v]; i++ {
+		f(int(s.chains[i]), int(s.pos[i]))
+	}
+}
+
+// Replaces the row of v by the given (chain, position) pairs. The row is
+// rewritten in place if it fits or is stored last, otherwise it is appended
+// and its previous entries become unused. The arrays only shrink when they
+// are compacted, so empty rows never point past their end.
+func (s *sparseScheme[T]) writeRow(v int, chains []uint32, pos []T) {
+	old := s.end[v] - s.start[v]
+	switch {
+	case len(chains) <= old:
+		copy(s.chains[s.start[v]:], chains)
+		copy(s.pos[s.start[v]:], pos)
+		s.dead += old - len(chains)
+	case s.end[v] == len(s.chains):
+		s.chains = append(s.chains[:s.start[v]], chains...)
+		s.pos = append(s.pos[:s.start[v]], pos...)
+	default:
+		s.dead += old
+		s.start[v] = len(s.chains)
+		s.chains = append(s.chains, chains...)
+		s.pos = append(s.pos, pos...)
+	}
+	s.end[v] = s.start[v] + len(chains)
+	// Compacting costs O(|V| + entries), which the unused entries pay for
+	if 2*s.dead > len(s.chains)-s.dead+s.rows {
+		s.compact()
+	}
+}
+
+// Moves all rows to the front of the arrays in the order of the vertices,
+// dropping the unused entries between them in O(|V| + entries).
+func (s *sparseScheme[T]) compact() {
+	live := len(s.chains) - s.dead
+	chains := make([]uint32, 0, live)
+	pos := make([]T, 0, live)
+	for v := 0; v < s.rows; v++ {
+		start := len(chains)
+		chains = append(chains, s.chains[s.start[v]:s.end[v]]...)
+		pos = append(pos, s.pos[s.start[v]:s.end[v]]...)
+		s.start[v], s.end[v] = start, len(chains)
+	}
+	s.chains, s.pos, s.dead = chains, pos, 0
+}
+
+func (s *sparseScheme[T]) lower(v, c, pos int) {
+	if s.Get(v, c) <= pos {
+		return
+	}
+	var chains []uint32
+	var positions []T
+	inserted := false
+	s.eachEntry(v, func(c2, pos2 int) {
+		if !inserted && c2 >= c {
+			chains = append(chains, uint32(c))
+			positions = append(positions, T(pos))
+			inserted = true
+		}
+		if c2 != c {
+			chains = append(chains, uint32(c2))
+			positions = append(positions, T(pos2))
+		}
+	})
+	if !inserted {
+		chains = append(chains, uint32(c))
+		positions = append(positions, T(pos))
+	}
+	s.writeRow(v, chains, positions)
+}
+
+func (s *sparseScheme[T]) setRow(v int, row []int) {
+	var chains []uint32
+	var positions []T
+	for c, pos := range row {
+		if !isInfinite(pos) {
+			chains = append(chains, uint32(c))
+			positions = append(positions, T(pos))
+		}
+	}
+	s.writeRow(v, chains, positions)
+}
+
+func (s *sparseScheme[T]) grow(rows, chains int) {
+	// Only built schemes grow, the row offsets of a mapped one share their array
+	s.start = append(s.start, make([]int, rows)...)
+	s.end = append(s.end, make([]int, rows)...)
+	s.rows += rows
+	s.k += chains
+}
+
+func (s *sparseScheme[T]) mergeRow(v, w int) {
+	var chains []uint32
+	var positions []T
+	i, j := s.start[v], s.start[w]
+	for i < s.end[v] || j < s.end[w] {
+		switch {
+		case j == s.end[w] || (i < s.end[v] && s.chains[i] < s.chains[j]):
+			chains = append(chains, s.chains[i])
+			positions = append(positions, s.pos[i])
+			i++
+		case i == s.end[v] || s.chains[j] < s.chains[i]:
+			chains = append(chains, s.chains[j])
+			positions = append(positions, s.pos[j])
+			j++
+		default:
+			chains = append(chains, s.chains[i])
+			positions = append(positions, min(s.pos[i], s.pos[j]))
+			i++
+			j++
+		}
+	}
+	s.writeRow(v, chains, positions)
+}
+
+// Writes the row offsets as u64 words, the chains as u32 words padded to
+// a multiple of 8 bytes and the positions as words of Width bytes.
+func (s *sparseScheme[T]) writeTo(w io.Writer) error {
+	buf := make([]byte, 0, 8*s.k)
+	write := func() error {
+		_, err := w.Write(buf)
+		buf = buf[:0]
+		return err
+	}
+	offset := 0
+	for v := 0; v <= s.rows; v++ {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(offset))
+		if v < s.rows {
+			offset += s.end[v] - s.start[v]
+		}
+		if err := write(); err != nil {
+			return err
+		}
+	}
+	for v := 0; v < s.rows; v++ {
+		for _, c := range s.chains[s.start[v]:s.end[v]] {
+			buf = binary.LittleEndian.AppendUint32(buf, c)
+		}
+		if err := write(); err != nil {
+			return err
+		}
+	}
+	if offset%2 == 1 {
+		buf = binary.LittleEndian.AppendUint32(buf, 0)
+	}
+	for v := 0; v < s.rows; v++ {
+		for _, pos := range s.pos[s.start[v]:s.end[v]] {
+			buf = appendEntry(buf, uint64(pos), s.width)
+		}
+		if err := write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the number of bytes writeTo writes for a scheme with the given
+// number of rows, entries and width.
+func sparseSchemeSize(rows, entries, width int) int {
+	return 8*(rows+1) + 4*(entries+entries%2) + entries*width
+}
+
+func (s *sparseScheme[T]) readFrom(r io.Reader, entries int) error {
+	buf := make([]byte, sparseSchemeSize(s.rows, entries, s.width)-8*(s.rows+1))
+	offsets := make([]byte, 8*(s.rows+1))
+	if _, err := io.ReadFull(r, offsets); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	prev := 0
+	for v := 0; v <= s.rows; v++ {
+		offset := binary.LittleEndian.Uint64(offsets[8*v:])
+		if offset < uint64(prev) || offset > uint64(entries) {
+			return ErrIndexCorrupt
+		}
+		if v < s.rows {
+			s.start[v] = int(offset)
+		}
+		if v > 0 {
+			s.end[v-1] = int(offset)
+		}
+		prev = int(offset)
+	}
+	s.chains = make([]uint32, entries)
+	s.pos = make([]T, entries)
+	for i := range s.chains {
+		s.chains[i] = binary.LittleEndian.Uint32(buf[4*i:])
+		if int(s.chains[i]) >= s.k {
+			return ErrIndexCorrupt
+		}
+	}
+	posBuf := buf[4*(entries+entries%2):]
+	for i := range s.pos {
+		s.pos[i] = T(entryAt(posBuf, i, s.width))
+	}
+	return nil
+}
+
+// Computes the row of v from the rows of its successors. Only finite entries
+// are merged, collected in the scratch row that is infinite outside of touched.
+// Assumes the outgoing edges of v are sorted in topological order.
+func (s *sparseScheme[T]) fillRow(v int, g *CSRGraph, decomp *Decomposition, scratch []int, touched []int) []int {
+	g.stats.SchemeEdges += uint(g.outDeg(v))
+	touched = s.collectRow(v, g, decomp, scratch, touched)
+	s.start[v] = len(s.chains)
+	for _, c := range touched {
+		s.chains = append(s.chains, uint32(c))
+		s.pos = append(s.pos, T(scratch[c]))
+		scratch[c] = math.MaxInt
+	}
+	s.end[v] = len(s.chains)
+	return touched
+}
+
+// Merges the rows of the successors of v into scratch and returns the
+// sorted chains whose entries it set. Only reads the scheme.
+func (s *sparseScheme[T]) collectRow(v int, g *CSRGraph, decomp *Decomposition, scratch []int, touched []int) []int {
+	touched = touched[:0]
+	for _, t := range g.outs(v) {
+		tChain := decomp.vToChain[t].chain.val.id
+		tPos := decomp.vToChain[t].pos
+		if scratch[tChain] <= tPos {
+			// v already reaches the target through an earlier edge
+			continue
+		}
+		for i := s.start[t]; i < s.end[t]; i++ {
+			c := int(s.chains[i])
+			if isInfinite(scratch[c]) {
+				touched = append(touched, c)
+			}
+			scratch[c] = min(scratch[c], int(s.pos[i]))
+		}
+		if isInfinite(scratch[tChain]) {
+			touched = append(touched, tChain)
+		}
+		scratch[tChain] = min(scratch[tChain], tPos)
+	}
+	sort.Ints(touched)
+	return touched
+}
+
+// Fills the rows of the vertices in topo from back to front, starting at
+// index i, until done or the sparse rows use more than maxBytes.
+// Returns the index of the next row to fill, or -1 if all rows are filled.
+func (s *sparseScheme[T]) fill(g *CSRGraph, topo []int, i int, decomp *Decomposition, maxBytes int) int {
+	scratch := make([]int, s.k)
+	for c := range scratch {
+		scratch[c] = math.MaxInt
+	}
+	var touched []int
+	for ; i >= 0 && s.bytes() <= maxBytes; i-- {
+		g.stats.SchemeNodes++
+		touched = s.fillRow(topo[i], g, decomp, scratch, touched)
+	}
+	return i
+}
+
+// Copies all rows into a dense scheme of the same width.
+func (s *sparseScheme[T]) toDense() *packedScheme[T] {
+	dense := createPackedScheme[T](s.rows, s.k, s.width)
+	for v := 0; v < s.rows; v++ {
+		s.eachEntry(v, func(c, pos int) {
+			dense.lower(v, c, pos)
+		})
+	}
+	return dense
+}
@@ -1,9 +1,4 @@
-package main
-
-import (
-	"fmt"
-	"os"
-)
+package fruit
 
 type Stack[T any] struct {
 	data []T
@@ -27,22 +22,26 @@ func (s *Stack[T]) ClearStack() {
 	s.data = s.data[:0]
 }
 
-func (s *Stack[T]) Pop() T {
+// Removes and returns the top element.
+// Returns ErrEmptyStack if there is none.
+func (s *Stack[T]) Pop() (T, error) {
+	var top T
 	l := len(s.data)
 	if l == 0 {
-		fmt.Fprintln(os.Stderr, "Cannot Pop. Stack is already empty.")
-		os.Exit(1)
+		return top, ErrEmptyStack
 	}
-	top := s.data[l-1]
+	top = s.data[l-1]
 	s.data = s.data[:l-1]
-	return top
+	return top, nil
 }
 
-func (s *Stack[T]) Peek() T {
+// Returns the top element without removing it.
+// Returns ErrEmptyStack if there is none.
+func (s *Stack[T]) Peek() (T, error) {
+	var top T
 	l := len(s.data)
 	if l == 0 {
-		fmt.Fprintln(os.Stderr, "Cannot Peek. Stack is already empty.")
-		os.Exit(1)
+		return top, ErrEmptyStack
 	}
-	return (s.data)[l-1]
+	return s.data[l-1], nil
 }
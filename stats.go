@@ -0,0 +1,68 @@
+package fruit
+
+import (
+	"fmt"
+	"io"
+)
+
+// BuildStats collects the operation counters and phase timings of one index build.
+// All times are given in milliseconds.
+type BuildStats struct {
+	Nodes         int // vertices of the input graph
+	Edges         int // edges of the input graph
+	SCCs          int
+	Chains        int
+	RemovedEdges  uint
+	CollapseNodes uint
+	CollapseEdges uint
+	DecompNodes   uint
+	DecompEdges   uint
+	SchemeNodes   uint
+	SchemeEdges   uint
+	SchemeWidth   int  // bytes per scheme position
+	SchemeSparse  bool // whether only finite entries are stored
+	SchemeEntries int  // stored scheme entries
+
+	ReadingTime     float64 // set by the caller that read the graph
+	TotalTime       float64 // set by the caller that started the measurement
+	CompTime        float64
+	CollapseTime    float64
+	TopoTime        float64
+	DecompTime      float64
+	RemoveEdgesTime float64
+	TopoEdgesTime   float64
+	PreprocessTime  float64
+	SchemeTime      float64
+}
+
+// Returns the number of entries of the indexing scheme.
+func (s *BuildStats) SchemeSize() int {
+	return s.SCCs * s.Chains
+}
+
+// Writes the statistics as a single line in the format used by the benchmark scripts.
+func (s *BuildStats) Print(w io.Writer) {
+	fmt.Fprintln(w,
+		"#nodes: ", s.Nodes, ", #edges: ", s.Edges,
+		", #scc: ", s.SCCs,
+		", #chains: ", s.Chains,
+		", scheme-size: ", s.SchemeSize(),
+		", #removed-edges: ", s.RemovedEdges,
+		", #collapse-nodes: ", s.CollapseNodes,
+		", #collapse-edges: ", s.CollapseEdges,
+		", #decomp-nodes: ", s.DecompNodes,
+		", #decomp-edges: ", s.DecompEdges,
+		", #scheme-nodes: ", s.SchemeNodes,
+		", #scheme-edges: ", s.SchemeEdges,
+		", time-decomp: ", fmt.Sprintf("%.4f ms", s.DecompTime),
+		", time-preprocess: ", fmt.Sprintf("%.4f ms", s.PreprocessTime),
+		", time-scheme: ", fmt.Sprintf("%.4f ms", s.SchemeTime),
+		", time-reading: ", fmt.Sprintf("%.4f ms", s.ReadingTime),
+		", time-comp: ", fmt.Sprintf("%.4f ms", s.CompTime),
+		", time-total: ", fmt.Sprintf("%.4f ms", s.TotalTime),
+		", time-collapse: ", fmt.Sprintf("%.4f ms", s.CollapseTime),
+		", time-topo: ", fmt.Sprintf("%.4f ms", s.TopoTime),
+		", time-remove_edges: ", fmt.Sprintf("%.4f ms", s.RemoveEdgesTime),
+		", time-topo_edges_time: ", fmt.Sprintf("%.4f ms", s.TopoEdgesTime),
+	)
+}
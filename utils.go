@@ -1,4 +1,4 @@
-package main
+package fruit
 
 import (
 	"fmt"
@@ -23,26 +23,6 @@ func (l *Log) Print(args ...interface{}) {
 	}
 }
 
-func printMatrix(matrix [][]bool) {
-	if !verboseFlag {
-		return
-	}
-	for i := 0; i < len(matrix); i++ {
-		fmt.Print("[ ")
-		for j := 0; j < len(matrix[i]); j++ {
-			if matrix[i][j] {
-				fmt.Print("T")
-			} else {
-				fmt.Print("F")
-			}
-			if j < len(matrix[i])-1 {
-				fmt.Print(", ")
-			}
-		}
-		fmt.Println(" ]")
-	}
-}
-
 // Compares two matrices for equality.
 func compQuadraticMatrices(m1 [][]bool, m2 [][]bool) bool {
 	if len(m1) != len(m2) {
@@ -58,13 +38,13 @@ func compQuadraticMatrices(m1 [][]bool, m2 [][]bool) bool {
 	return true
 }
 
-func collectGraphFiles(dir string) []string {
+// Returns the paths of all regular files below dir.
+func collectGraphFiles(dir string) ([]string, error) {
 	var graphFiles []string
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error while walking through directory!")
-			os.Exit(1)
+			return err
 		}
 		if !info.IsDir() {
 			graphFiles = append(graphFiles, path)
@@ -73,11 +53,10 @@ func collectGraphFiles(dir string) []string {
 	})
 
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error while reading directory!")
-		os.Exit(1)
+		return nil, fmt.Errorf("collecting graph files in %s: %w", dir, err)
 	}
 
-	return graphFiles
+	return graphFiles, nil
 }
 
 func getTimeMS(start time.Time) float64 {